@@ -0,0 +1,184 @@
+// Package agent is Pronto's reference node agent. It derives a node's signal
+// from Linux PSI, cgroup v2 and /proc/stat, and streams it to the
+// scheduler's SignalService.
+package agent
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "sync"
+    "time"
+
+    "github.com/LucaChot/pronto-framework/client"
+    "github.com/LucaChot/pronto-framework/linalg"
+    pb "github.com/LucaChot/pronto-framework/message"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/trace"
+    "go.opentelemetry.io/otel/trace/noop"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "k8s.io/klog/v2"
+)
+
+// Config configures an Agent.
+type Config struct {
+    // Node is the name of the node the agent runs on.
+    Node string
+    // Scheduler is the address of the scheduler's SignalService.
+    Scheduler string
+    // Discover streams to every scheduler replica behind Scheduler's host.
+    Discover bool
+    // QueryAddress, if set, also serves the latest signal over the
+    // SignalQuery service for schedulers that scrape their agents.
+    QueryAddress string
+    // Interval between samples.
+    Interval time.Duration
+    // MaxBackoff caps the delay before reconnecting to the scheduler.
+    MaxBackoff time.Duration
+    Paths      Paths
+    Model      Model
+    PCA        PCAConfig
+    // Tracer starts a span for every sample, whose trace context is sent
+    // with the signal. Defaults to a no-op tracer.
+    Tracer trace.Tracer
+}
+
+// Agent samples its node and reports the signal to the scheduler.
+type Agent struct {
+    pb.UnimplementedSignalQueryServer
+    cfg Config
+
+    // prev is the previous Stats, for the CPU utilization between samples.
+    prev *Stats
+
+    mu     sync.Mutex
+    latest *pb.Signal
+
+    // pca is the local subspace and cluster the merged one, when PCA is
+    // enabled.
+    pcaMu   sync.Mutex
+    pca     *streamingPCA
+    cluster *linalg.Matrix
+}
+
+func New(cfg Config) *Agent {
+    if cfg.Tracer == nil {
+        cfg.Tracer = noop.NewTracerProvider().Tracer("")
+    }
+    a := &Agent{cfg: cfg}
+    if cfg.PCA.Enabled {
+        a.pca = newStreamingPCA(cfg.PCA.Rank, cfg.PCA.BatchSize, cfg.PCA.Forget)
+    }
+    return a
+}
+
+// Run reports the node's signal until ctx is done.
+func (a *Agent) Run(ctx context.Context) error {
+    logger := klog.FromContext(ctx).WithValues("node", a.cfg.Node)
+    if a.cfg.QueryAddress != "" {
+        if err := a.serveQuery(ctx, logger); err != nil {
+            return err
+        }
+    }
+
+    reporter, err := client.NewReporter(client.Options{
+        Node:       a.cfg.Node,
+        Target:     a.cfg.Scheduler,
+        Discover:   a.cfg.Discover,
+        MaxBackoff: a.cfg.MaxBackoff,
+    })
+    if err != nil {
+        return err
+    }
+    go reporter.Run(ctx)
+
+    if a.pca != nil {
+        if err := a.startMerging(ctx, logger); err != nil {
+            return err
+        }
+    }
+
+    ticker := time.NewTicker(a.cfg.Interval)
+    defer ticker.Stop()
+    for {
+        sampleCtx, span := a.cfg.Tracer.Start(ctx, "pronto-agent/Sample",
+            trace.WithAttributes(attribute.String("pronto.node", a.cfg.Node)))
+        m, err := a.sample()
+        if err != nil {
+            span.RecordError(err)
+            logger.Error(err, "Sampling node")
+        } else if m != nil {
+            span.SetAttributes(attribute.Float64("pronto.signal", m.Signal),
+                attribute.Float64("pronto.capacity", m.Capacity))
+            reporter.Report(sampleCtx, m)
+        }
+        span.End()
+
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-ticker.C:
+        }
+    }
+}
+
+// sample reads the node's Stats and computes its signal. The first sample
+// only primes the CPU counters and returns nil.
+func (a *Agent) sample() (*pb.Signal, error) {
+    cur, err := ReadStats(a.cfg.Paths)
+    if err != nil {
+        return nil, err
+    }
+    prev := a.prev
+    a.prev = cur
+    if prev == nil {
+        return nil, nil
+    }
+
+    features := Features(prev, cur)
+    signal, capacity, overprovision := a.cfg.Model.Compute(features)
+    if a.pca != nil {
+        signal = a.rejectionSignal(features, signal)
+    }
+    m := &pb.Signal{
+        Node:          a.cfg.Node,
+        Signal:        signal,
+        Capacity:      capacity,
+        Overprovision: overprovision,
+    }
+    a.mu.Lock()
+    a.latest = m
+    a.mu.Unlock()
+    return m, nil
+}
+
+// GetSignal returns the latest signal.
+func (a *Agent) GetSignal(ctx context.Context, _ *pb.SignalRequest) (*pb.Signal, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if a.latest == nil {
+        return nil, status.Error(codes.Unavailable, "no signal sampled yet")
+    }
+    return a.latest, nil
+}
+
+func (a *Agent) serveQuery(ctx context.Context, logger klog.Logger) error {
+    lis, err := net.Listen("tcp", a.cfg.QueryAddress)
+    if err != nil {
+        return fmt.Errorf("listening on %s: %w", a.cfg.QueryAddress, err)
+    }
+    s := grpc.NewServer()
+    pb.RegisterSignalQueryServer(s, a)
+    go func() {
+        <-ctx.Done()
+        s.GracefulStop()
+    }()
+    go func() {
+        if err := s.Serve(lis); err != nil {
+            logger.Error(err, "SignalQuery server failed")
+        }
+    }()
+    return nil
+}
@@ -0,0 +1,124 @@
+package agent
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/LucaChot/pronto-framework/linalg"
+    pb "github.com/LucaChot/pronto-framework/message"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/metadata"
+    "k8s.io/apimachinery/pkg/util/wait"
+    "k8s.io/klog/v2"
+)
+
+// PCAConfig configures federated PCA. The agent tracks the dominant
+// subspace of its Features, periodically merges its basis, scaled by the
+// singular values, with every other node's through the AggregateMerge
+// service, and reports as its signal the rejection signal: the relative
+// reconstruction error of its current Features against the merged cluster
+// subspace. Until the first merge the local subspace is used, and until the
+// first batch the Model's signal.
+type PCAConfig struct {
+    Enabled bool
+    // Aggregator is the address of the AggregateMerge service. Empty uses
+    // the scheduler's address.
+    Aggregator string
+    // Rank is the dimension of the tracked subspace.
+    Rank int
+    // BatchSize is the number of samples merged into the subspace at once.
+    BatchSize int
+    // Forget scales the subspace's singular values at each batch, so older
+    // samples fade out.
+    Forget float64
+    // MergeInterval is the interval between merges with the cluster.
+    MergeInterval time.Duration
+}
+
+func DefaultPCAConfig() PCAConfig {
+    return PCAConfig{
+        Rank:          2,
+        BatchSize:     10,
+        Forget:        0.9,
+        MergeInterval: 30 * time.Second,
+    }
+}
+
+// startMerging merges the local subspace with the cluster's every
+// MergeInterval until ctx is done.
+func (a *Agent) startMerging(ctx context.Context, logger klog.Logger) error {
+    address := a.cfg.PCA.Aggregator
+    if address == "" {
+        address = a.cfg.Scheduler
+    }
+    conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        return fmt.Errorf("creating client for %s: %w", address, err)
+    }
+    client := pb.NewAggregateMergeClient(conn)
+    go func() {
+        defer conn.Close()
+        wait.UntilWithContext(ctx, func(ctx context.Context) {
+            if err := a.merge(ctx, client); err != nil {
+                logger.Error(err, "Merging subspace with the cluster")
+            }
+        }, a.cfg.PCA.MergeInterval)
+    }()
+    return nil
+}
+
+func (a *Agent) merge(ctx context.Context, client pb.AggregateMergeClient) error {
+    a.pcaMu.Lock()
+    local := a.pca.scaled
+    a.pcaMu.Unlock()
+    if local == nil {
+        return nil
+    }
+
+    ctx, cancel := context.WithTimeout(ctx, a.cfg.PCA.MergeInterval)
+    defer cancel()
+    if a.cfg.Node != "" {
+        ctx = metadata.AppendToOutgoingContext(ctx, pb.NodeMetadataKey, a.cfg.Node)
+    }
+    reply, err := client.RequestAggMerge(ctx, local.ToDense())
+    if err != nil {
+        return err
+    }
+    merged, err := linalg.FromDense(reply)
+    if err != nil {
+        return err
+    }
+    if merged.Rows != local.Rows {
+        return fmt.Errorf("merged subspace has %d dimensions, want %d", merged.Rows, local.Rows)
+    }
+    basis := linalg.Basis(merged)
+
+    a.pcaMu.Lock()
+    a.cluster = basis
+    a.pcaMu.Unlock()
+    return nil
+}
+
+// rejectionSignal adds features to the local subspace and returns their
+// reconstruction error against the cluster subspace, or signal if there is
+// no subspace yet.
+func (a *Agent) rejectionSignal(features []float64, signal float64) float64 {
+    a.pcaMu.Lock()
+    defer a.pcaMu.Unlock()
+
+    a.pca.observe(features)
+    basis := a.cluster
+    if basis == nil {
+        basis = a.pca.basis
+    }
+    if basis == nil {
+        return signal
+    }
+    rejection, err := linalg.ReconstructionError(basis, features)
+    if err != nil {
+        return signal
+    }
+    return rejection
+}
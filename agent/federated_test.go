@@ -0,0 +1,124 @@
+package agent
+
+import (
+    "context"
+    "math"
+    "net"
+    "testing"
+
+    "github.com/LucaChot/pronto-framework/linalg"
+    pb "github.com/LucaChot/pronto-framework/message"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/test/bufconn"
+)
+
+// testAggregator is an AggregateMerge service that merges the subspace it
+// receives with the other nodes', and records the node that sent it.
+type testAggregator struct {
+    pb.UnimplementedAggregateMergeServer
+    others *linalg.Matrix
+    node   string
+}
+
+func (s *testAggregator) RequestAggMerge(ctx context.Context, m *pb.DenseMatrix) (*pb.DenseMatrix, error) {
+    if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(pb.NodeMetadataKey)) > 0 {
+        s.node = md.Get(pb.NodeMetadataKey)[0]
+    }
+    update, err := linalg.FromDense(m)
+    if err != nil {
+        return nil, err
+    }
+    merged, err := linalg.Merge(s.others, update, 1, 2)
+    if err != nil {
+        return nil, err
+    }
+    return merged.ToDense(), nil
+}
+
+// fixedAggregator replies to every merge with the same subspace.
+type fixedAggregator struct {
+    pb.UnimplementedAggregateMergeServer
+    reply *pb.DenseMatrix
+}
+
+func (s *fixedAggregator) RequestAggMerge(context.Context, *pb.DenseMatrix) (*pb.DenseMatrix, error) {
+    return s.reply, nil
+}
+
+func newAggregateMergeClient(t *testing.T, srv pb.AggregateMergeServer) pb.AggregateMergeClient {
+    lis := bufconn.Listen(1 << 16)
+    s := grpc.NewServer()
+    pb.RegisterAggregateMergeServer(s, srv)
+    go s.Serve(lis)
+    t.Cleanup(s.Stop)
+
+    conn, err := grpc.NewClient("passthrough:///aggregator",
+        grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+        grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { conn.Close() })
+    return pb.NewAggregateMergeClient(conn)
+}
+
+func TestFederatedMerge(t *testing.T) {
+    cfg := DefaultPCAConfig()
+    cfg.Enabled = true
+    cfg.BatchSize = 2
+    a := New(Config{Node: "node-1", PCA: cfg})
+
+    // Another node has already contributed the memory axis.
+    agg := &testAggregator{others: &linalg.Matrix{Rows: 5, Cols: 1, Data: []float64{0, 3, 0, 0, 0}}}
+    client := newAggregateMergeClient(t, agg)
+
+    if err := a.merge(context.Background(), client); err != nil {
+        t.Fatalf("merge before the first batch: %v", err)
+    }
+    if a.cluster != nil {
+        t.Fatal("merged before the local subspace existed")
+    }
+
+    // This node's load varies along the CPU axis only. Until it merges, a
+    // memory-bound sample lies outside its subspace.
+    cpuBound := []float64{0.5, 0, 0, 0, 0}
+    memoryBound := []float64{0, 0.5, 0, 0, 0}
+    a.rejectionSignal(cpuBound, 0)
+    a.rejectionSignal([]float64{0.25, 0, 0, 0, 0}, 0)
+    if got := a.rejectionSignal(memoryBound, 0); math.Abs(got-1) > 1e-9 {
+        t.Errorf("rejection before merging = %v, want 1", got)
+    }
+
+    if err := a.merge(context.Background(), client); err != nil {
+        t.Fatal(err)
+    }
+    if agg.node != "node-1" {
+        t.Errorf("merge named node %q, want node-1", agg.node)
+    }
+    for name, x := range map[string][]float64{"cpu": cpuBound, "memory": memoryBound} {
+        if got := a.rejectionSignal(x, 0); got > 1e-9 {
+            t.Errorf("%s-bound rejection after merging = %v, want 0", name, got)
+        }
+    }
+    if got := a.rejectionSignal([]float64{0, 0, 0.5, 0, 0}, 0); math.Abs(got-1) > 1e-9 {
+        t.Errorf("pressure-bound rejection after merging = %v, want 1", got)
+    }
+}
+
+func TestFederatedMergeRejectsOtherDimension(t *testing.T) {
+    cfg := DefaultPCAConfig()
+    cfg.Enabled = true
+    cfg.BatchSize = 1
+    a := New(Config{PCA: cfg})
+    a.rejectionSignal([]float64{1, 0, 0, 0, 0}, 0)
+
+    agg := &fixedAggregator{reply: &pb.DenseMatrix{Rows: 3, Cols: 1, Data: []float64{1, 0, 0}}}
+    if err := a.merge(context.Background(), newAggregateMergeClient(t, agg)); err == nil {
+        t.Error("merge accepted a subspace of another dimension")
+    }
+    if a.cluster != nil {
+        t.Error("merge kept a subspace of another dimension")
+    }
+}
@@ -0,0 +1,57 @@
+package agent
+
+import "math"
+
+// Model turns two consecutive Stats into a Pronto signal:
+//
+//	utilization   = max(cpu, memory)
+//	pressure      = max(cpu, memory and io PSI)
+//	signal        = max(utilization, pressure)
+//	capacity      = max(0, TargetUtilization - signal) / PodShare
+//	overprovision = (1 - max(signal, TargetUtilization)) / PodShare * (1 - pressure)
+//
+// cpu is the busy fraction of /proc/stat between the samples and memory is
+// the pods' cgroup memory over its limit. Capacity is the number of pods,
+// each assumed to use PodShare of the node, that fit before the node reaches
+// TargetUtilization. Overprovision is the number that fit between the
+// target and saturation, discounted while the node is under pressure.
+type Model struct {
+    TargetUtilization float64
+    PodShare          float64
+}
+
+// DefaultModel aims for 70% utilization with pods using 5% of the node.
+func DefaultModel() Model {
+    return Model{TargetUtilization: 0.7, PodShare: 0.05}
+}
+
+// Features are the node's utilization and pressure between two Stats:
+// CPU and memory utilization followed by the CPU, memory and IO pressure,
+// each a fraction in [0, 1].
+func Features(prev, cur *Stats) []float64 {
+    var cpu float64
+    if cur.CPUTotal > prev.CPUTotal && cur.CPUBusy >= prev.CPUBusy {
+        cpu = float64(cur.CPUBusy-prev.CPUBusy) / float64(cur.CPUTotal-prev.CPUTotal)
+    }
+    var memory float64
+    if cur.MemoryLimit > 0 {
+        memory = float64(cur.MemoryUsed) / float64(cur.MemoryLimit)
+    }
+    return []float64{clamp01(cpu), clamp01(memory), clamp01(cur.CPUPressure), clamp01(cur.MemoryPressure), clamp01(cur.IOPressure)}
+}
+
+// Compute returns the signal, capacity and overprovision for the node's
+// Features.
+func (m Model) Compute(features []float64) (signal, capacity, overprovision float64) {
+    cpu, memory := features[0], features[1]
+    pressure := math.Max(features[2], math.Max(features[3], features[4]))
+
+    signal = math.Max(math.Max(cpu, memory), pressure)
+    capacity = math.Max(0, m.TargetUtilization-signal) / m.PodShare
+    overprovision = math.Max(0, 1-math.Max(signal, m.TargetUtilization)) / m.PodShare * (1 - pressure)
+    return signal, capacity, overprovision
+}
+
+func clamp01(v float64) float64 {
+    return math.Min(1, math.Max(0, v))
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+    "math"
+    "reflect"
+    "testing"
+)
+
+func TestModel(t *testing.T) {
+    prev := &Stats{CPUBusy: 1000, CPUTotal: 5000}
+    cur := &Stats{
+        CPUBusy:        1200,
+        CPUTotal:       6000,
+        MemoryUsed:     1 << 30,
+        MemoryLimit:    2 << 30,
+        CPUPressure:    0.1,
+        MemoryPressure: 0.05,
+        IOPressure:     1.5,
+    }
+    features := Features(prev, cur)
+    if want := []float64{0.2, 0.5, 0.1, 0.05, 1}; !reflect.DeepEqual(features, want) {
+        t.Fatalf("Features = %v, want %v", features, want)
+    }
+
+    tests := []struct {
+        name                            string
+        features                        []float64
+        signal, capacity, overprovision float64
+    }{
+        {
+            name:          "memory bound",
+            features:      []float64{0.2, 0.5, 0.1, 0, 0},
+            signal:        0.5,
+            capacity:      4,
+            overprovision: 5.4,
+        },
+        {
+            name:          "over target",
+            features:      []float64{0.9, 0.1, 0, 0, 0},
+            signal:        0.9,
+            capacity:      0,
+            overprovision: 2,
+        },
+        {
+            name:          "saturated by pressure",
+            features:      features,
+            signal:        1,
+            capacity:      0,
+            overprovision: 0,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            signal, capacity, overprovision := DefaultModel().Compute(tt.features)
+            for _, v := range []struct {
+                name      string
+                got, want float64
+            }{
+                {"signal", signal, tt.signal},
+                {"capacity", capacity, tt.capacity},
+                {"overprovision", overprovision, tt.overprovision},
+            } {
+                if math.Abs(v.got-v.want) > 1e-9 {
+                    t.Errorf("%s = %v, want %v", v.name, v.got, v.want)
+                }
+            }
+        })
+    }
+}
+
+func TestFeaturesCounterReset(t *testing.T) {
+    // After a reboot the counters restart below the previous sample.
+    got := Features(&Stats{CPUBusy: 500, CPUTotal: 1000}, &Stats{CPUBusy: 10, CPUTotal: 20})
+    if got[0] != 0 {
+        t.Errorf("cpu = %v, want 0", got[0])
+    }
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+    "github.com/LucaChot/pronto-framework/linalg"
+)
+
+// streamingPCA tracks the dominant subspace of the node's Features. Each
+// batch of samples is merged into the current subspace, whose singular
+// values are first scaled by the forgetting factor so older samples fade
+// out.
+type streamingPCA struct {
+    rank      int
+    forget    float64
+    batchSize int
+
+    // scaled is the subspace's basis scaled by its singular values, and
+    // basis its orthonormal basis.
+    scaled *linalg.Matrix
+    basis  *linalg.Matrix
+    batch  [][]float64
+}
+
+func newStreamingPCA(rank, batchSize int, forget float64) *streamingPCA {
+    return &streamingPCA{rank: rank, forget: forget, batchSize: batchSize}
+}
+
+// observe adds a sample, updating the subspace once a batch is complete.
+func (p *streamingPCA) observe(x []float64) {
+    p.batch = append(p.batch, append([]float64(nil), x...))
+    if len(p.batch) < p.batchSize {
+        return
+    }
+
+    samples := linalg.New(len(x), len(p.batch))
+    for j, sample := range p.batch {
+        for i, v := range sample {
+            samples.Set(i, j, v)
+        }
+    }
+    p.batch = p.batch[:0]
+
+    scaled, err := linalg.Merge(p.scaled, samples, p.forget, p.rank)
+    if err != nil {
+        // The Features' dimension changed; start over.
+        scaled, _ = linalg.Merge(nil, samples, p.forget, p.rank)
+    }
+    p.scaled = scaled
+    p.basis = linalg.Basis(scaled)
+}
@@ -0,0 +1,178 @@
+package agent
+
+import (
+    "bufio"
+    "bytes"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// Paths locates the kernel files the agent reads, so a containerised agent
+// can read the host's through a mount and tests can use fixtures.
+type Paths struct {
+    // ProcStat is /proc/stat.
+    ProcStat string
+    // MemInfo is /proc/meminfo.
+    MemInfo string
+    // PressureDir holds the PSI files cpu, memory and io.
+    PressureDir string
+    // Cgroup is the cgroup v2 directory holding the node's pods.
+    Cgroup string
+}
+
+// DefaultPaths are the host's own files, with pods under the systemd
+// cgroup driver's kubepods.slice.
+func DefaultPaths() Paths {
+    return Paths{
+        ProcStat:    "/proc/stat",
+        MemInfo:     "/proc/meminfo",
+        PressureDir: "/proc/pressure",
+        Cgroup:      "/sys/fs/cgroup/kubepods.slice",
+    }
+}
+
+// Stats is a snapshot of the node's CPU, memory and pressure.
+type Stats struct {
+    // CPUBusy and CPUTotal are the jiffies spent busy and in total by every
+    // CPU since boot.
+    CPUBusy  uint64
+    CPUTotal uint64
+    // MemoryUsed is the cgroup's memory.current and MemoryLimit its
+    // memory.max, or the node's MemTotal if it has none, in bytes.
+    MemoryUsed  uint64
+    MemoryLimit uint64
+    // The PSI "some" avg10 of each resource, as a fraction of time.
+    CPUPressure    float64
+    MemoryPressure float64
+    IOPressure     float64
+}
+
+// ReadStats reads the node's current Stats.
+func ReadStats(p Paths) (*Stats, error) {
+    s := &Stats{}
+    var err error
+    if s.CPUBusy, s.CPUTotal, err = readProcStat(p.ProcStat); err != nil {
+        return nil, err
+    }
+    if s.MemoryUsed, err = readUint(filepath.Join(p.Cgroup, "memory.current")); err != nil {
+        return nil, err
+    }
+    if s.MemoryLimit, err = readMemoryLimit(p); err != nil {
+        return nil, err
+    }
+    for resource, into := range map[string]*float64{
+        "cpu":    &s.CPUPressure,
+        "memory": &s.MemoryPressure,
+        "io":     &s.IOPressure,
+    } {
+        if *into, err = readPressure(filepath.Join(p.PressureDir, resource)); err != nil {
+            return nil, err
+        }
+    }
+    return s, nil
+}
+
+// readProcStat sums the aggregate cpu line of /proc/stat. Guest time is
+// already included in user time, and iowait is counted as idle.
+func readProcStat(path string) (busy, total uint64, err error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return 0, 0, err
+    }
+    line, _, _ := bytes.Cut(data, []byte("\n"))
+    fields := strings.Fields(string(line))
+    if len(fields) < 5 || fields[0] != "cpu" {
+        return 0, 0, fmt.Errorf("%s: unexpected first line %q", path, line)
+    }
+    // user nice system idle iowait irq softirq steal
+    var idle uint64
+    for i, f := range fields[1:min(len(fields), 9)] {
+        v, err := strconv.ParseUint(f, 10, 64)
+        if err != nil {
+            return 0, 0, fmt.Errorf("%s: %w", path, err)
+        }
+        total += v
+        if i == 3 || i == 4 {
+            idle += v
+        }
+    }
+    return total - idle, total, nil
+}
+
+// readMemoryLimit reads the cgroup's memory.max, falling back to MemTotal
+// when the cgroup is unlimited.
+func readMemoryLimit(p Paths) (uint64, error) {
+    path := filepath.Join(p.Cgroup, "memory.max")
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return 0, err
+    }
+    if v := strings.TrimSpace(string(data)); v != "max" {
+        limit, err := strconv.ParseUint(v, 10, 64)
+        if err != nil {
+            return 0, fmt.Errorf("%s: %w", path, err)
+        }
+        return limit, nil
+    }
+
+    f, err := os.Open(p.MemInfo)
+    if err != nil {
+        return 0, err
+    }
+    defer f.Close()
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        fields := strings.Fields(scanner.Text())
+        if len(fields) == 3 && fields[0] == "MemTotal:" && fields[2] == "kB" {
+            kb, err := strconv.ParseUint(fields[1], 10, 64)
+            if err != nil {
+                return 0, fmt.Errorf("%s: %w", p.MemInfo, err)
+            }
+            return kb * 1024, nil
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return 0, err
+    }
+    return 0, fmt.Errorf("%s: MemTotal not found", p.MemInfo)
+}
+
+// readPressure reads the "some" avg10 of a PSI file, such as
+// "some avg10=1.50 avg60=0.80 avg300=0.20 total=123456".
+func readPressure(path string) (float64, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return 0, err
+    }
+    for _, line := range strings.Split(string(data), "\n") {
+        fields := strings.Fields(line)
+        if len(fields) == 0 || fields[0] != "some" {
+            continue
+        }
+        for _, f := range fields[1:] {
+            if v, ok := strings.CutPrefix(f, "avg10="); ok {
+                pct, err := strconv.ParseFloat(v, 64)
+                if err != nil {
+                    return 0, fmt.Errorf("%s: %w", path, err)
+                }
+                return pct / 100, nil
+            }
+        }
+    }
+    return 0, fmt.Errorf("%s: some avg10 not found", path)
+}
+
+func readUint(path string) (uint64, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return 0, err
+    }
+    v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+    if err != nil {
+        return 0, fmt.Errorf("%s: %w", path, err)
+    }
+    return v, nil
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func testPaths(cgroup string) Paths {
+    return Paths{
+        ProcStat:    filepath.Join("testdata", "stat"),
+        MemInfo:     filepath.Join("testdata", "meminfo"),
+        PressureDir: filepath.Join("testdata", "pressure"),
+        Cgroup:      filepath.Join("testdata", cgroup),
+    }
+}
+
+func TestReadStats(t *testing.T) {
+    tests := []struct {
+        name  string
+        paths Paths
+        want  *Stats
+    }{
+        {
+            name:  "memory.max set",
+            paths: testPaths("cgroup"),
+            want: &Stats{
+                CPUBusy:        1350,
+                CPUTotal:       9550,
+                MemoryUsed:     2 << 30,
+                MemoryLimit:    4 << 30,
+                CPUPressure:    0.125,
+                MemoryPressure: 0.03,
+                IOPressure:     0.4,
+            },
+        },
+        {
+            name:  "memory.max unlimited",
+            paths: testPaths("cgroup-unlimited"),
+            want: &Stats{
+                CPUBusy:        1350,
+                CPUTotal:       9550,
+                MemoryUsed:     1 << 30,
+                MemoryLimit:    8167876 * 1024,
+                CPUPressure:    0.125,
+                MemoryPressure: 0.03,
+                IOPressure:     0.4,
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := ReadStats(tt.paths)
+            if err != nil {
+                t.Fatalf("ReadStats: %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("ReadStats = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+// writeFixture writes contents to a file in a temporary directory and
+// returns its path.
+func writeFixture(t *testing.T, name, contents string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), name)
+    if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestReadProcStat(t *testing.T) {
+    tests := []struct {
+        name        string
+        contents    string
+        busy, total uint64
+        wantErr     bool
+    }{
+        {
+            // Kernels before 2.6.11 have no steal column.
+            name:     "old kernel",
+            contents: "cpu  100 0 50 800 50 0 0\n",
+            busy:     150,
+            total:    1000,
+        },
+        {
+            name:     "minimal",
+            contents: "cpu  1 2 3 4\n",
+            busy:     6,
+            total:    10,
+        },
+        {
+            name:     "per-cpu first",
+            contents: "cpu0 1 2 3 4 5\n",
+            wantErr:  true,
+        },
+        {
+            name:     "too few fields",
+            contents: "cpu  1 2 3\n",
+            wantErr:  true,
+        },
+        {
+            name:     "not a number",
+            contents: "cpu  1 2 x 4 5\n",
+            wantErr:  true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            busy, total, err := readProcStat(writeFixture(t, "stat", tt.contents))
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("readProcStat error = %v, want error %v", err, tt.wantErr)
+            }
+            if busy != tt.busy || total != tt.total {
+                t.Errorf("readProcStat = %d, %d, want %d, %d", busy, total, tt.busy, tt.total)
+            }
+        })
+    }
+}
+
+func TestReadPressure(t *testing.T) {
+    tests := []struct {
+        name     string
+        contents string
+        want     float64
+        wantErr  bool
+    }{
+        {
+            // The cpu file has no full line before Linux 5.13.
+            name:     "some only",
+            contents: "some avg10=0.75 avg60=0.50 avg300=0.25 total=1000\n",
+            want:     0.0075,
+        },
+        {
+            name:     "full first",
+            contents: "full avg10=9.00 avg60=0 avg300=0 total=0\nsome avg10=50.00 avg60=0 avg300=0 total=0\n",
+            want:     0.5,
+        },
+        {
+            name:     "no some line",
+            contents: "full avg10=9.00 avg60=0 avg300=0 total=0\n",
+            wantErr:  true,
+        },
+        {
+            name:     "bad avg10",
+            contents: "some avg10=x avg60=0 avg300=0 total=0\n",
+            wantErr:  true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := readPressure(writeFixture(t, "cpu", tt.contents))
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("readPressure error = %v, want error %v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("readPressure = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestReadMemoryLimitErrors(t *testing.T) {
+    for name, contents := range map[string]string{
+        "bad memory.max": "lots\n",
+        "no MemTotal":    "max\n",
+    } {
+        t.Run(name, func(t *testing.T) {
+            dir := filepath.Dir(writeFixture(t, "memory.max", contents))
+            p := Paths{Cgroup: dir, MemInfo: writeFixture(t, "meminfo", "MemFree: 1 kB\n")}
+            if _, err := readMemoryLimit(p); err == nil {
+                t.Error("readMemoryLimit succeeded, want an error")
+            }
+        })
+    }
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+    "context"
+    "sync"
+    "sync/atomic"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+)
+
+// signalBuffer is a bounded FIFO of signals that drops its oldest signal
+// when full, since a newer signal supersedes it.
+type signalBuffer struct {
+    mu      sync.Mutex
+    items   []*pb.Signal
+    size    int
+    ready   chan struct{}
+    dropped *atomic.Uint64
+}
+
+func newSignalBuffer(size int, dropped *atomic.Uint64) *signalBuffer {
+    return &signalBuffer{
+        size:    size,
+        ready:   make(chan struct{}, 1),
+        dropped: dropped,
+    }
+}
+
+// push appends m, dropping the oldest signal if the buffer is full.
+func (b *signalBuffer) push(m *pb.Signal) {
+    b.mu.Lock()
+    if len(b.items) == b.size {
+        b.items = b.items[1:]
+        b.dropped.Add(1)
+    }
+    b.items = append(b.items, m)
+    b.mu.Unlock()
+
+    select {
+    case b.ready <- struct{}{}:
+    default:
+    }
+}
+
+// requeue puts back a signal that failed to send, unless newer signals
+// already fill the buffer.
+func (b *signalBuffer) requeue(m *pb.Signal) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if len(b.items) == b.size {
+        b.dropped.Add(1)
+        return
+    }
+    b.items = append([]*pb.Signal{m}, b.items...)
+}
+
+// pop removes the oldest signal, waiting for one until ctx is done.
+func (b *signalBuffer) pop(ctx context.Context) (*pb.Signal, error) {
+    for {
+        b.mu.Lock()
+        if len(b.items) > 0 {
+            m := b.items[0]
+            b.items = b.items[1:]
+            b.mu.Unlock()
+            return m, nil
+        }
+        b.mu.Unlock()
+
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        case <-b.ready:
+        }
+    }
+}
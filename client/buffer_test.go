@@ -0,0 +1,39 @@
+package client
+
+import (
+    "context"
+    "reflect"
+    "sync/atomic"
+    "testing"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+)
+
+func TestSignalBufferDropsOldest(t *testing.T) {
+    var dropped atomic.Uint64
+    b := newSignalBuffer(2, &dropped)
+    for _, v := range []float64{1, 2, 3} {
+        b.push(&pb.Signal{Signal: v})
+    }
+    // A failed send of 2 cannot go back in front of the newer 3 and 4.
+    m, _ := b.pop(context.Background())
+    b.push(&pb.Signal{Signal: 4})
+    b.requeue(m)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    var got []float64
+    for {
+        m, err := b.pop(ctx)
+        if err != nil {
+            break
+        }
+        got = append(got, m.GetSignal())
+    }
+    if want := []float64{3, 4}; !reflect.DeepEqual(got, want) {
+        t.Errorf("popped %v, want %v", got, want)
+    }
+    if got := dropped.Load(); got != 2 {
+        t.Errorf("dropped %d, want 2", got)
+    }
+}
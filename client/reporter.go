@@ -0,0 +1,330 @@
+// Package client streams node signals to Pronto's SignalService.
+package client
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "go.opentelemetry.io/otel/propagation"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/keepalive"
+    "google.golang.org/grpc/metadata"
+    "k8s.io/apimachinery/pkg/util/wait"
+    "k8s.io/component-base/tracing"
+    "k8s.io/klog/v2"
+)
+
+// Options configures a Reporter.
+type Options struct {
+    // Node is set on every reported signal.
+    Node string
+    // Target is the host:port of the scheduler, e.g.
+    // pronto-svc.kube-system.svc:50051.
+    Target string
+    // Discover resolves Target's host every ResolveInterval and streams to
+    // every address, so each scheduler replica behind a headless service
+    // receives every signal.
+    Discover        bool
+    ResolveInterval time.Duration
+    // BufferSize is the number of signals buffered per scheduler while it is
+    // unreachable. The oldest signal is dropped when the buffer is full.
+    BufferSize int
+    // InitialBackoff is the delay before reconnecting to a scheduler, which
+    // doubles with each failed attempt up to MaxBackoff.
+    InitialBackoff time.Duration
+    MaxBackoff     time.Duration
+    // KeepaliveTime is the interval between keepalive pings on an idle
+    // connection.
+    KeepaliveTime time.Duration
+    // DialOptions are added to the defaults, insecure credentials and
+    // keepalive.
+    DialOptions []grpc.DialOption
+    // LookupHost resolves Target's host when Discover is set. Defaults to
+    // net.DefaultResolver.
+    LookupHost func(ctx context.Context, host string) ([]string, error)
+}
+
+// DefaultOptions are the defaults for the unset fields of Options.
+func DefaultOptions() Options {
+    return Options{
+        ResolveInterval: 30 * time.Second,
+        BufferSize:      16,
+        InitialBackoff:  time.Second,
+        MaxBackoff:      30 * time.Second,
+        KeepaliveTime:   30 * time.Second,
+        LookupHost:      net.DefaultResolver.LookupHost,
+    }
+}
+
+// ErrClosed is returned by Report once the Reporter's Run has returned.
+var ErrClosed = errors.New("reporter is closed")
+
+// Reporter streams signals to one or every scheduler replica, reconnecting
+// with jittered exponential backoff.
+type Reporter struct {
+    opts    Options
+    dropped atomic.Uint64
+
+    mu        sync.Mutex
+    closed    bool
+    endpoints map[string]*endpoint
+    // latest is the last reported signal, queued for new endpoints.
+    latest *pb.Signal
+}
+
+// endpoint is the stream to one scheduler address.
+type endpoint struct {
+    address string
+    buf     *signalBuffer
+    cancel  context.CancelFunc
+    done    chan struct{}
+}
+
+// NewReporter fills the unset fields of opts from DefaultOptions.
+func NewReporter(opts Options) (*Reporter, error) {
+    def := DefaultOptions()
+    if opts.ResolveInterval <= 0 {
+        opts.ResolveInterval = def.ResolveInterval
+    }
+    if opts.BufferSize <= 0 {
+        opts.BufferSize = def.BufferSize
+    }
+    if opts.InitialBackoff <= 0 {
+        opts.InitialBackoff = def.InitialBackoff
+    }
+    if opts.MaxBackoff < opts.InitialBackoff {
+        opts.MaxBackoff = max(def.MaxBackoff, opts.InitialBackoff)
+    }
+    if opts.KeepaliveTime <= 0 {
+        opts.KeepaliveTime = def.KeepaliveTime
+    }
+    if opts.LookupHost == nil {
+        opts.LookupHost = def.LookupHost
+    }
+    if opts.Node == "" {
+        return nil, fmt.Errorf("node must be set")
+    }
+    if _, _, err := net.SplitHostPort(opts.Target); err != nil {
+        return nil, fmt.Errorf("target: %w", err)
+    }
+    return &Reporter{opts: opts, endpoints: make(map[string]*endpoint)}, nil
+}
+
+// Report queues a signal for every scheduler, setting its node and the trace
+// context of ctx. It never blocks on the network.
+func (r *Reporter) Report(ctx context.Context, s *pb.Signal) error {
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    m := &pb.Signal{
+        Node:          r.opts.Node,
+        Signal:        s.GetSignal(),
+        Capacity:      s.GetCapacity(),
+        Overprovision: s.GetOverprovision(),
+        TraceContext:  traceContext(ctx, s.GetTraceContext()),
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.closed {
+        return ErrClosed
+    }
+    r.latest = m
+    for _, ep := range r.endpoints {
+        ep.buf.push(m)
+    }
+    return nil
+}
+
+// Dropped returns the number of signals dropped from full buffers.
+func (r *Reporter) Dropped() uint64 {
+    return r.dropped.Load()
+}
+
+// Run streams the reported signals until ctx is done.
+func (r *Reporter) Run(ctx context.Context) error {
+    logger := klog.FromContext(ctx)
+    if !r.opts.Discover {
+        r.sync(ctx, []string{r.opts.Target})
+    } else {
+        host, port, _ := net.SplitHostPort(r.opts.Target)
+        wait.UntilWithContext(ctx, func(ctx context.Context) {
+            addrs, err := r.opts.LookupHost(ctx, host)
+            if err != nil {
+                logger.Error(err, "Resolving schedulers", "host", host)
+                return
+            }
+            targets := make([]string, 0, len(addrs))
+            for _, addr := range addrs {
+                targets = append(targets, net.JoinHostPort(addr, port))
+            }
+            r.sync(ctx, targets)
+        }, r.opts.ResolveInterval)
+    }
+    <-ctx.Done()
+
+    r.mu.Lock()
+    r.closed = true
+    endpoints := r.endpoints
+    r.endpoints = nil
+    r.mu.Unlock()
+    for _, ep := range endpoints {
+        <-ep.done
+    }
+    return nil
+}
+
+// sync starts streaming to new addresses and stops streaming to those that
+// have gone.
+func (r *Reporter) sync(ctx context.Context, addresses []string) {
+    live := make(map[string]bool, len(addresses))
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for _, address := range addresses {
+        live[address] = true
+        if _, ok := r.endpoints[address]; ok {
+            continue
+        }
+        epCtx, cancel := context.WithCancel(ctx)
+        ep := &endpoint{
+            address: address,
+            buf:     newSignalBuffer(r.opts.BufferSize, &r.dropped),
+            cancel:  cancel,
+            done:    make(chan struct{}),
+        }
+        if r.latest != nil {
+            ep.buf.push(r.latest)
+        }
+        r.endpoints[address] = ep
+        go r.run(epCtx, ep)
+    }
+    for address, ep := range r.endpoints {
+        if !live[address] {
+            ep.cancel()
+            delete(r.endpoints, address)
+        }
+    }
+}
+
+// run keeps a stream open to an endpoint until ctx is done.
+func (r *Reporter) run(ctx context.Context, ep *endpoint) {
+    defer close(ep.done)
+    logger := klog.FromContext(ctx).WithValues("scheduler", ep.address)
+
+    opts := append([]grpc.DialOption{
+        grpc.WithTransportCredentials(insecure.NewCredentials()),
+        grpc.WithKeepaliveParams(keepalive.ClientParameters{
+            Time:                r.opts.KeepaliveTime,
+            PermitWithoutStream: true,
+        }),
+    }, r.opts.DialOptions...)
+    conn, err := grpc.NewClient(ep.address, opts...)
+    if err != nil {
+        logger.Error(err, "Creating client")
+        return
+    }
+    defer conn.Close()
+    client := pb.NewSignalServiceClient(conn)
+
+    b := newBackoff(r.opts.InitialBackoff, r.opts.MaxBackoff)
+    for {
+        sent, err := r.stream(ctx, client, ep.buf)
+        if ctx.Err() != nil {
+            return
+        }
+        if sent {
+            b.reset()
+        }
+        retry := b.next()
+        logger.Error(err, "Signal stream failed", "retryAfter", retry)
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(retry):
+        }
+    }
+}
+
+// backoffJitter is the fraction of the delay added at random to each retry,
+// so agents that lost the same scheduler do not reconnect in lockstep.
+const backoffJitter = 0.2
+
+// backoff is an exponential backoff from initial, doubling up to max.
+type backoff struct {
+    initial, max, delay time.Duration
+}
+
+func newBackoff(initial, max time.Duration) *backoff {
+    return &backoff{initial: initial, max: max, delay: initial}
+}
+
+// next returns the delay before the next retry, in
+// [delay, delay*(1+backoffJitter)), and doubles the delay.
+func (b *backoff) next() time.Duration {
+    retry := wait.Jitter(b.delay, backoffJitter)
+    b.delay = min(2*b.delay, b.max)
+    return retry
+}
+
+func (b *backoff) reset() {
+    b.delay = b.initial
+}
+
+// stream sends buffered signals until the stream fails or ctx is done,
+// returning whether any signal was sent. The stream's metadata carries the
+// trace context of ctx.
+func (r *Reporter) stream(ctx context.Context, client pb.SignalServiceClient, buf *signalBuffer) (bool, error) {
+    var kv []string
+    for k, v := range traceContext(ctx, nil) {
+        kv = append(kv, k, v)
+    }
+    stream, err := client.StreamSignals(metadata.AppendToOutgoingContext(ctx, kv...))
+    if err != nil {
+        return false, err
+    }
+    sent := false
+    for {
+        // The stream's context ends with the stream, e.g. when the
+        // scheduler restarts, so an idle stream reconnects straight away.
+        m, err := buf.pop(stream.Context())
+        if err != nil {
+            if ctx.Err() == nil {
+                _, err = stream.CloseAndRecv()
+            } else {
+                stream.CloseSend()
+            }
+            return sent, err
+        }
+        if err := stream.Send(m); err != nil {
+            buf.requeue(m)
+            // The stream's status is only returned by CloseAndRecv.
+            if errors.Is(err, io.EOF) {
+                _, err = stream.CloseAndRecv()
+            }
+            return sent, err
+        }
+        sent = true
+    }
+}
+
+// traceContext returns the W3C trace context of ctx, over that in base,
+// or nil if there is none.
+func traceContext(ctx context.Context, base map[string]string) map[string]string {
+    carrier := propagation.MapCarrier{}
+    for k, v := range base {
+        carrier[k] = v
+    }
+    tracing.Propagators().Inject(ctx, carrier)
+    if len(carrier) == 0 {
+        return nil
+    }
+    return carrier
+}
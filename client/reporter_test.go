@@ -0,0 +1,248 @@
+package client
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net"
+    "sort"
+    "sync"
+    "testing"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "go.opentelemetry.io/otel/trace"
+    "google.golang.org/grpc"
+    grpcbackoff "google.golang.org/grpc/backoff"
+    "google.golang.org/grpc/test/bufconn"
+)
+
+// testScheduler is a SignalService that forwards the signals it receives.
+type testScheduler struct {
+    pb.UnimplementedSignalServiceServer
+    address string
+    signals chan *pb.Signal
+}
+
+func (s *testScheduler) StreamSignals(stream pb.SignalService_StreamSignalsServer) error {
+    for {
+        m, err := stream.Recv()
+        if errors.Is(err, io.EOF) {
+            return stream.SendAndClose(&pb.SignalAck{})
+        }
+        if err != nil {
+            return err
+        }
+        s.signals <- m
+    }
+}
+
+// testNetwork routes the Reporter's connections to in-memory schedulers by
+// address. Addresses with no scheduler refuse connections.
+type testNetwork struct {
+    t         *testing.T
+    mu        sync.Mutex
+    listeners map[string]*bufconn.Listener
+    dials     chan string
+}
+
+func newTestNetwork(t *testing.T) *testNetwork {
+    return &testNetwork{t: t, listeners: map[string]*bufconn.Listener{}, dials: make(chan string, 100)}
+}
+
+// start serves a new scheduler on address, replacing any stopped one.
+func (n *testNetwork) start(address string) (*testScheduler, *grpc.Server) {
+    lis := bufconn.Listen(1 << 16)
+    s := &testScheduler{address: address, signals: make(chan *pb.Signal, 100)}
+    srv := grpc.NewServer()
+    pb.RegisterSignalServiceServer(srv, s)
+    go srv.Serve(lis)
+    n.t.Cleanup(srv.Stop)
+
+    n.mu.Lock()
+    n.listeners[address] = lis
+    n.mu.Unlock()
+    return s, srv
+}
+
+func (n *testNetwork) dial(ctx context.Context, address string) (net.Conn, error) {
+    select {
+    case n.dials <- address:
+    default:
+    }
+    n.mu.Lock()
+    lis := n.listeners[address]
+    n.mu.Unlock()
+    if lis == nil {
+        return nil, errors.New("connection refused")
+    }
+    return lis.DialContext(ctx)
+}
+
+// run starts a Reporter over the network, stopping it at the end of the test.
+func (n *testNetwork) run(opts Options) *Reporter {
+    opts.Node = "node-a"
+    opts.InitialBackoff = 10 * time.Millisecond
+    opts.MaxBackoff = 50 * time.Millisecond
+    opts.DialOptions = append(opts.DialOptions,
+        grpc.WithContextDialer(n.dial),
+        grpc.WithConnectParams(grpc.ConnectParams{
+            Backoff:           grpcbackoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond},
+            MinConnectTimeout: time.Second,
+        }))
+    r, err := NewReporter(opts)
+    if err != nil {
+        n.t.Fatal(err)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan struct{})
+    go func() {
+        r.Run(ctx)
+        close(done)
+    }()
+    n.t.Cleanup(func() {
+        cancel()
+        <-done
+    })
+    return r
+}
+
+// receive returns the next signal the scheduler receives.
+func receive(t *testing.T, s *testScheduler) *pb.Signal {
+    t.Helper()
+    select {
+    case m := <-s.signals:
+        return m
+    case <-time.After(5 * time.Second):
+        t.Fatalf("scheduler %s received no signal", s.address)
+        return nil
+    }
+}
+
+func report(t *testing.T, r *Reporter, signal float64) {
+    t.Helper()
+    if err := r.Report(context.Background(), &pb.Signal{Signal: signal}); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestReporterDropsOldestWhileUnreachable(t *testing.T) {
+    const address = "10.0.0.1:50051"
+    n := newTestNetwork(t)
+    r := n.run(Options{Target: address, BufferSize: 2})
+    // Wait for the endpoint to exist, so every report is buffered for it.
+    <-n.dials
+    for v := 1; v <= 5; v++ {
+        report(t, r, float64(v))
+    }
+
+    s, _ := n.start(address)
+    for _, want := range []float64{4, 5} {
+        if got := receive(t, s).GetSignal(); got != want {
+            t.Errorf("received signal %v, want %v", got, want)
+        }
+    }
+    if got := r.Dropped(); got != 3 {
+        t.Errorf("Dropped = %d, want 3", got)
+    }
+}
+
+func TestReporterReconnectsAfterRestart(t *testing.T) {
+    const address = "10.0.0.1:50051"
+    n := newTestNetwork(t)
+    s, srv := n.start(address)
+    r := n.run(Options{Target: address})
+
+    report(t, r, 1)
+    if got := receive(t, s).GetSignal(); got != 1 {
+        t.Fatalf("received signal %v, want 1", got)
+    }
+
+    srv.Stop()
+    restarted, _ := n.start(address)
+    // A signal sent as the old stream dies is lost, so keep reporting as an
+    // agent would until the new scheduler hears from it.
+    deadline := time.After(5 * time.Second)
+    for v := 2; ; v++ {
+        report(t, r, float64(v))
+        select {
+        case m := <-restarted.signals:
+            if m.GetNode() != "node-a" || m.GetSignal() < 2 {
+                t.Errorf("received %v after restart, want a new signal from node-a", m)
+            }
+            return
+        case <-time.After(20 * time.Millisecond):
+        case <-deadline:
+            t.Fatal("no signal reached the restarted scheduler")
+        }
+    }
+}
+
+func TestReporterDiscoversEveryReplica(t *testing.T) {
+    n := newTestNetwork(t)
+    replicas := map[string]*testScheduler{}
+    for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+        replicas[ip], _ = n.start(net.JoinHostPort(ip, "50051"))
+    }
+    r := n.run(Options{
+        Target:          "pronto-svc.kube-system.svc:50051",
+        Discover:        true,
+        ResolveInterval: time.Hour,
+        LookupHost: func(ctx context.Context, host string) ([]string, error) {
+            if host != "pronto-svc.kube-system.svc" {
+                t.Errorf("resolved %q, want the target's host", host)
+            }
+            var ips []string
+            for ip := range replicas {
+                ips = append(ips, ip)
+            }
+            sort.Strings(ips)
+            return ips, nil
+        },
+    })
+
+    // Discovery runs asynchronously; the latest signal is queued for each
+    // replica it finds.
+    report(t, r, 1)
+    for ip, s := range replicas {
+        if got := receive(t, s).GetSignal(); got != 1 {
+            t.Errorf("replica %s received signal %v, want 1", ip, got)
+        }
+    }
+}
+
+func TestReporterPropagatesTraceContext(t *testing.T) {
+    const address = "10.0.0.1:50051"
+    n := newTestNetwork(t)
+    s, _ := n.start(address)
+    r := n.run(Options{Target: address})
+
+    sc := trace.NewSpanContext(trace.SpanContextConfig{
+        TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+        SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+        TraceFlags: trace.FlagsSampled,
+    })
+    ctx := trace.ContextWithSpanContext(context.Background(), sc)
+    if err := r.Report(ctx, &pb.Signal{Signal: 1}); err != nil {
+        t.Fatal(err)
+    }
+    want := "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01"
+    if got := receive(t, s).GetTraceContext()["traceparent"]; got != want {
+        t.Errorf("traceparent = %q, want %q", got, want)
+    }
+}
+
+func TestBackoff(t *testing.T) {
+    b := newBackoff(100*time.Millisecond, time.Second)
+    for i, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+        want *= time.Millisecond
+        got := b.next()
+        if got < want || got >= want+time.Duration(float64(want)*backoffJitter) {
+            t.Errorf("retry %d after %v, want [%v, %v)", i, got, want, want+time.Duration(float64(want)*backoffJitter))
+        }
+    }
+    b.reset()
+    if got := b.next(); got >= 120*time.Millisecond {
+        t.Errorf("retry after reset after %v, want under 120ms", got)
+    }
+}
@@ -0,0 +1,97 @@
+// Command pronto-agent is Pronto's reference node agent.
+package main
+
+import (
+    "context"
+    "flag"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
+
+    "github.com/LucaChot/pronto-framework/agent"
+    "go.opentelemetry.io/otel/sdk/resource"
+    semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+    "k8s.io/component-base/tracing"
+    tracingapi "k8s.io/component-base/tracing/api/v1"
+    "k8s.io/klog/v2"
+)
+
+func main() {
+    paths := agent.DefaultPaths()
+    model := agent.DefaultModel()
+    pca := agent.DefaultPCAConfig()
+    cfg := agent.Config{}
+    var tracingEndpoint string
+    var samplingRate int
+
+    klog.InitFlags(nil)
+    flag.StringVar(&cfg.Node, "node", os.Getenv("NODE_NAME"), "name of the node the agent runs on")
+    flag.StringVar(&cfg.Scheduler, "scheduler", "pronto-svc.kube-system.svc:50051", "address of the scheduler's SignalService")
+    flag.BoolVar(&cfg.Discover, "discover", false, "stream to every scheduler replica that --scheduler's host resolves to")
+    flag.StringVar(&cfg.QueryAddress, "query-address", "", "address to serve the SignalQuery service on, empty to disable")
+    flag.DurationVar(&cfg.Interval, "interval", time.Second, "interval between samples")
+    flag.DurationVar(&cfg.MaxBackoff, "max-backoff", 30*time.Second, "maximum delay before reconnecting to the scheduler")
+    flag.StringVar(&paths.ProcStat, "proc-stat", paths.ProcStat, "path of /proc/stat")
+    flag.StringVar(&paths.MemInfo, "meminfo", paths.MemInfo, "path of /proc/meminfo")
+    flag.StringVar(&paths.PressureDir, "pressure-dir", paths.PressureDir, "directory holding the PSI files")
+    flag.StringVar(&paths.Cgroup, "cgroup", paths.Cgroup, "cgroup v2 directory holding the node's pods")
+    flag.Float64Var(&model.TargetUtilization, "target-utilization", model.TargetUtilization, "utilization at which the node has no capacity left")
+    flag.Float64Var(&model.PodShare, "pod-share", model.PodShare, "fraction of the node a pod is assumed to use")
+    flag.BoolVar(&pca.Enabled, "pca", false, "report the federated PCA rejection signal instead of the model's signal")
+    flag.StringVar(&pca.Aggregator, "aggregator", "", "address of the AggregateMerge service, defaults to --scheduler")
+    flag.IntVar(&pca.Rank, "pca-rank", pca.Rank, "dimension of the tracked subspace")
+    flag.IntVar(&pca.BatchSize, "pca-batch", pca.BatchSize, "samples merged into the subspace at once")
+    flag.Float64Var(&pca.Forget, "pca-forget", pca.Forget, "forgetting factor applied to the subspace at each batch")
+    flag.DurationVar(&pca.MergeInterval, "pca-merge-interval", pca.MergeInterval, "interval between merges with the cluster subspace")
+    flag.StringVar(&tracingEndpoint, "tracing-endpoint", "", "OTLP gRPC endpoint to export sample spans to, empty to disable")
+    flag.IntVar(&samplingRate, "tracing-sampling-rate-per-million", 1000000, "sample spans exported per million")
+    flag.Parse()
+    cfg.Paths = paths
+    cfg.Model = model
+    cfg.PCA = pca
+
+    if cfg.Node == "" {
+        hostname, err := os.Hostname()
+        if err != nil {
+            klog.ErrorS(err, "Node name not set and hostname unavailable")
+            os.Exit(1)
+        }
+        cfg.Node = hostname
+    }
+    if cfg.Interval <= 0 || model.PodShare <= 0 {
+        klog.ErrorS(nil, "--interval and --pod-share must be positive")
+        os.Exit(1)
+    }
+    if samplingRate < 0 || samplingRate > 1000000 {
+        klog.ErrorS(nil, "--tracing-sampling-rate-per-million must be in [0, 1000000]")
+        os.Exit(1)
+    }
+    if pca.Enabled && (pca.Rank <= 0 || pca.BatchSize <= 0 || pca.Forget <= 0 || pca.Forget > 1 || pca.MergeInterval <= 0) {
+        klog.ErrorS(nil, "--pca-rank, --pca-batch and --pca-merge-interval must be positive and --pca-forget in (0, 1]")
+        os.Exit(1)
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+    ctx = klog.NewContext(ctx, klog.Background())
+
+    if tracingEndpoint != "" {
+        rate := int32(samplingRate)
+        tp, err := tracing.NewProvider(ctx, &tracingapi.TracingConfiguration{
+            Endpoint:               &tracingEndpoint,
+            SamplingRatePerMillion: &rate,
+        }, nil, []resource.Option{resource.WithAttributes(semconv.ServiceName("pronto-agent"))})
+        if err != nil {
+            klog.ErrorS(err, "Creating tracer provider")
+            os.Exit(1)
+        }
+        defer tp.Shutdown(context.Background())
+        cfg.Tracer = tp.Tracer("github.com/LucaChot/pronto-framework/agent")
+    }
+
+    if err := agent.New(cfg).Run(ctx); err != nil {
+        klog.ErrorS(err, "Agent failed")
+        os.Exit(1)
+    }
+}
@@ -0,0 +1,78 @@
+// Command pronto-replay streams a recording of the signals received by a
+// Pronto scheduler into a scheduler's SignalService, with one stream per
+// node as the agents had.
+package main
+
+import (
+    "context"
+    "flag"
+    "os"
+    "os/signal"
+    "syscall"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "github.com/LucaChot/pronto-framework/record"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "k8s.io/klog/v2"
+)
+
+func main() {
+    file := flag.String("file", "", "recording to replay")
+    target := flag.String("target", "localhost:50051", "address of the scheduler's SignalService")
+    speed := flag.Float64("speed", 1, "replay speed relative to the recording, 0 for as fast as possible")
+    klog.InitFlags(nil)
+    flag.Parse()
+
+    if *file == "" || *speed < 0 {
+        klog.ErrorS(nil, "--file must be set and --speed must not be negative")
+        os.Exit(1)
+    }
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    if err := replay(ctx, *file, *target, *speed); err != nil {
+        klog.ErrorS(err, "Replay failed")
+        os.Exit(1)
+    }
+}
+
+func replay(ctx context.Context, file, target string, speed float64) error {
+    r, err := record.Open(file)
+    if err != nil {
+        return err
+    }
+    defer r.Close()
+
+    conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    client := pb.NewSignalServiceClient(conn)
+
+    streams := map[string]pb.SignalService_StreamSignalsClient{}
+    defer func() {
+        for node, stream := range streams {
+            if _, err := stream.CloseAndRecv(); err != nil {
+                klog.ErrorS(err, "Closing stream", "node", node)
+            }
+        }
+    }()
+
+    sent := 0
+    err = record.Replay(ctx, r, speed, func(m *pb.Signal) error {
+        stream, ok := streams[m.GetNode()]
+        if !ok {
+            var err error
+            if stream, err = client.StreamSignals(ctx); err != nil {
+                return err
+            }
+            streams[m.GetNode()] = stream
+        }
+        sent++
+        return stream.Send(m)
+    })
+    klog.InfoS("Replayed recording", "signals", sent, "nodes", len(streams))
+    return err
+}
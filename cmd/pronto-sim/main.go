@@ -0,0 +1,89 @@
+// Command pronto-sim runs a discrete-event simulation of the Pronto plugin
+// scheduling a synthetic workload or a trace and reports its utilization,
+// rejection rate and overload time.
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "os"
+    "os/signal"
+    "syscall"
+
+    "github.com/LucaChot/pronto-framework/sim"
+    "k8s.io/klog/v2"
+)
+
+func main() {
+    configFile := flag.String("config", "", "YAML simulation config, defaults to sim.DefaultConfig")
+    out := flag.String("out", "", "file to write the full JSON result to")
+    seed := flag.Int64("seed", 0, "overrides the config's seed if set")
+    trace := flag.String("trace", "", "overrides the config's trace path if set")
+    traceFormat := flag.String("trace-format", "", "overrides the config's trace format if set: Pods, Borg, AlibabaTask, AlibabaInstance or Audit")
+    dump := flag.String("dump-workload", "", "file to write the simulated pods to, as a Pods trace")
+    klog.InitFlags(nil)
+    flag.Parse()
+
+    cfg, err := sim.LoadConfig(*configFile)
+    if err != nil {
+        klog.ErrorS(err, "Loading config")
+        os.Exit(1)
+    }
+    if *seed != 0 {
+        cfg.Seed = *seed
+    }
+    if *trace != "" {
+        cfg.Trace.Path = *trace
+    }
+    if *traceFormat != "" {
+        cfg.Trace.Format = sim.TraceFormat(*traceFormat)
+    }
+    if err := cfg.Validate(); err != nil {
+        klog.ErrorS(err, "Invalid config")
+        os.Exit(1)
+    }
+    pods, err := sim.LoadWorkload(cfg)
+    if err != nil {
+        klog.ErrorS(err, "Loading workload")
+        os.Exit(1)
+    }
+    if *dump != "" {
+        if err := writeFile(*dump, func(f *os.File) error { return sim.WriteTrace(f, pods) }); err != nil {
+            klog.ErrorS(err, "Writing workload", "file", *dump)
+            os.Exit(1)
+        }
+    }
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    result, err := sim.Run(ctx, cfg, pods)
+    if err != nil {
+        klog.ErrorS(err, "Simulation failed")
+        os.Exit(1)
+    }
+    result.WriteSummary(os.Stdout)
+    if *out != "" {
+        err := writeFile(*out, func(f *os.File) error {
+            enc := json.NewEncoder(f)
+            enc.SetIndent("", "  ")
+            return enc.Encode(result)
+        })
+        if err != nil {
+            klog.ErrorS(err, "Writing result", "file", *out)
+            os.Exit(1)
+        }
+    }
+}
+
+func writeFile(path string, write func(f *os.File) error) error {
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    if err := write(f); err != nil {
+        f.Close()
+        return err
+    }
+    return f.Close()
+}
@@ -0,0 +1,113 @@
+// Package linalg provides the dense linear algebra Pronto's federated PCA
+// needs: conversion of message.DenseMatrix, truncated SVD, subspace merging
+// and projection.
+package linalg
+
+import (
+    "fmt"
+    "math"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+)
+
+// Matrix is a dense row-major matrix.
+type Matrix struct {
+    Rows, Cols int
+    Data       []float64
+}
+
+// New returns a zero matrix.
+func New(rows, cols int) *Matrix {
+    return &Matrix{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+func (m *Matrix) At(i, j int) float64     { return m.Data[i*m.Cols+j] }
+func (m *Matrix) Set(i, j int, v float64) { m.Data[i*m.Cols+j] = v }
+
+// Clone returns a copy of m.
+func (m *Matrix) Clone() *Matrix {
+    return &Matrix{Rows: m.Rows, Cols: m.Cols, Data: append([]float64(nil), m.Data...)}
+}
+
+// Col returns a copy of column j.
+func (m *Matrix) Col(j int) []float64 {
+    col := make([]float64, m.Rows)
+    for i := range col {
+        col[i] = m.At(i, j)
+    }
+    return col
+}
+
+// MaxElements bounds the elements of a DenseMatrix accepted by Validate,
+// well above any subspace of node metrics.
+const MaxElements = 1 << 20
+
+// Validate checks that a DenseMatrix holds rows*cols finite elements, and at
+// most MaxElements of them.
+func Validate(d *pb.DenseMatrix) error {
+    rows, cols := d.GetRows(), d.GetCols()
+    if rows < 0 || cols < 0 {
+        return fmt.Errorf("dense matrix has negative dimensions %dx%d", rows, cols)
+    }
+    // Each dimension is bounded too, since an empty matrix can still have
+    // a huge one. The product is checked by division so it cannot overflow.
+    if rows > MaxElements || cols > MaxElements || (cols != 0 && rows > MaxElements/cols) {
+        return fmt.Errorf("dense matrix of %dx%d has more than %d elements", rows, cols, MaxElements)
+    }
+    if int64(len(d.GetData())) != rows*cols {
+        return fmt.Errorf("dense matrix of %dx%d has %d elements", rows, cols, len(d.GetData()))
+    }
+    for i, v := range d.GetData() {
+        if math.IsNaN(v) || math.IsInf(v, 0) {
+            return fmt.Errorf("dense matrix element %d is %v", i, v)
+        }
+    }
+    return nil
+}
+
+// FromDense copies a validated DenseMatrix.
+func FromDense(d *pb.DenseMatrix) (*Matrix, error) {
+    if err := Validate(d); err != nil {
+        return nil, err
+    }
+    return &Matrix{Rows: int(d.Rows), Cols: int(d.Cols), Data: append([]float64(nil), d.Data...)}, nil
+}
+
+// ToDense copies m into a DenseMatrix.
+func (m *Matrix) ToDense() *pb.DenseMatrix {
+    return &pb.DenseMatrix{Rows: int64(m.Rows), Cols: int64(m.Cols), Data: append([]float64(nil), m.Data...)}
+}
+
+// Concat places matrices with the same number of rows side by side.
+func Concat(ms ...*Matrix) (*Matrix, error) {
+    if len(ms) == 0 {
+        return New(0, 0), nil
+    }
+    rows, cols := ms[0].Rows, 0
+    for _, m := range ms {
+        if m.Rows != rows {
+            return nil, fmt.Errorf("cannot concatenate matrices with %d and %d rows", rows, m.Rows)
+        }
+        cols += m.Cols
+    }
+    out := New(rows, cols)
+    offset := 0
+    for _, m := range ms {
+        for i := 0; i < rows; i++ {
+            copy(out.Data[i*cols+offset:i*cols+offset+m.Cols], m.Data[i*m.Cols:(i+1)*m.Cols])
+        }
+        offset += m.Cols
+    }
+    return out, nil
+}
+
+// ScaleCols returns m with column j multiplied by s[j].
+func ScaleCols(m *Matrix, s []float64) *Matrix {
+    out := m.Clone()
+    for i := 0; i < m.Rows; i++ {
+        for j := 0; j < m.Cols; j++ {
+            out.Set(i, j, m.At(i, j)*s[j])
+        }
+    }
+    return out
+}
@@ -0,0 +1,60 @@
+package linalg
+
+import (
+    "math"
+    "reflect"
+    "testing"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+)
+
+func TestValidate(t *testing.T) {
+    tests := []struct {
+        name    string
+        d       *pb.DenseMatrix
+        wantErr bool
+    }{
+        {name: "valid", d: &pb.DenseMatrix{Rows: 2, Cols: 2, Data: []float64{1, 2, 3, 4}}},
+        {name: "empty", d: &pb.DenseMatrix{}},
+        {name: "negative rows", d: &pb.DenseMatrix{Rows: -1, Cols: 2}, wantErr: true},
+        {name: "missing elements", d: &pb.DenseMatrix{Rows: 2, Cols: 2, Data: []float64{1, 2, 3}}, wantErr: true},
+        {name: "extra elements", d: &pb.DenseMatrix{Rows: 1, Cols: 2, Data: []float64{1, 2, 3}}, wantErr: true},
+        {
+            // rows*cols wraps to 0 in 64 bits.
+            name:    "overflowing dimensions",
+            d:       &pb.DenseMatrix{Rows: 1 << 32, Cols: 1 << 32},
+            wantErr: true,
+        },
+        {name: "huge empty", d: &pb.DenseMatrix{Rows: 0, Cols: 1 << 40}, wantErr: true},
+        {name: "too many elements", d: &pb.DenseMatrix{Rows: MaxElements + 1, Cols: 1}, wantErr: true},
+        {name: "NaN", d: &pb.DenseMatrix{Rows: 1, Cols: 2, Data: []float64{1, math.NaN()}}, wantErr: true},
+        {name: "infinite", d: &pb.DenseMatrix{Rows: 2, Cols: 1, Data: []float64{math.Inf(-1), 1}}, wantErr: true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if err := Validate(tt.d); (err != nil) != tt.wantErr {
+                t.Errorf("Validate error = %v, want error %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestConcat(t *testing.T) {
+    a := &Matrix{Rows: 2, Cols: 1, Data: []float64{1, 2}}
+    b := &Matrix{Rows: 2, Cols: 2, Data: []float64{3, 4, 5, 6}}
+    got, err := Concat(a, b)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := &Matrix{Rows: 2, Cols: 3, Data: []float64{1, 3, 4, 2, 5, 6}}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("Concat = %+v, want %+v", got, want)
+    }
+
+    if _, err := Concat(a, New(3, 1)); err == nil {
+        t.Error("concatenated matrices with different rows")
+    }
+    if got, err := Concat(); err != nil || got.Rows != 0 || got.Cols != 0 {
+        t.Errorf("Concat() = %+v, %v, want an empty matrix", got, err)
+    }
+}
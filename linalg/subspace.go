@@ -0,0 +1,106 @@
+package linalg
+
+import (
+    "fmt"
+    "math"
+)
+
+// Merge combines two subspaces, each given as a basis scaled by its singular
+// values, into the rank-dimensional subspace of their union, also scaled.
+// The first subspace is weighted by the forgetting factor, so repeated
+// merges into it let old contributions fade. Either may be nil.
+func Merge(old, update *Matrix, forget float64, rank int) (*Matrix, error) {
+    var parts []*Matrix
+    if old != nil {
+        scaled := old.Clone()
+        for i := range scaled.Data {
+            scaled.Data[i] *= forget
+        }
+        parts = append(parts, scaled)
+    }
+    if update != nil {
+        parts = append(parts, update)
+    }
+    if len(parts) == 0 {
+        return nil, fmt.Errorf("nothing to merge")
+    }
+    joined, err := Concat(parts...)
+    if err != nil {
+        return nil, err
+    }
+    u, s := TruncatedSVD(joined, rank)
+    return ScaleCols(u, s), nil
+}
+
+// Gram returns m times its transpose. The Gram matrices of scaled subspaces
+// add up to the Gram matrix of their concatenation, so any number of them
+// can be merged with FromGram at the cost of one SVD of a square matrix of
+// their dimension.
+func Gram(m *Matrix) *Matrix {
+    g := New(m.Rows, m.Rows)
+    for i := 0; i < m.Rows; i++ {
+        for j := i; j < m.Rows; j++ {
+            var sum float64
+            for k := 0; k < m.Cols; k++ {
+                sum += m.At(i, k) * m.At(j, k)
+            }
+            g.Set(i, j, sum)
+            g.Set(j, i, sum)
+        }
+    }
+    return g
+}
+
+// FromGram returns the rank-dimensional scaled subspace of the matrices
+// whose Gram matrices sum to g, as Merge would return for them without
+// forgetting.
+func FromGram(g *Matrix, rank int) *Matrix {
+    // g is symmetric and positive semi-definite, so its singular vectors are
+    // the subspace's and its singular values their squared scales.
+    u, s := TruncatedSVD(g, rank)
+    for i := range s {
+        s[i] = math.Sqrt(s[i])
+    }
+    return ScaleCols(u, s)
+}
+
+// Basis returns an orthonormal basis of the column space of m, such as a
+// scaled basis returned by Merge.
+func Basis(m *Matrix) *Matrix {
+    u, _ := SVD(m)
+    return u
+}
+
+// Project returns the coordinates of x in the orthonormal basis.
+func Project(basis *Matrix, x []float64) ([]float64, error) {
+    if len(x) != basis.Rows {
+        return nil, fmt.Errorf("vector of %d dimensions in a %d-dimensional space", len(x), basis.Rows)
+    }
+    coords := make([]float64, basis.Cols)
+    for j := range coords {
+        for i, v := range x {
+            coords[j] += basis.At(i, j) * v
+        }
+    }
+    return coords, nil
+}
+
+// ReconstructionError is the norm of the part of x outside the span of the
+// orthonormal basis, relative to the norm of x. It is 0 for a zero x.
+func ReconstructionError(basis *Matrix, x []float64) (float64, error) {
+    coords, err := Project(basis, x)
+    if err != nil {
+        return 0, err
+    }
+    xNorm := norm(x)
+    if xNorm == 0 {
+        return 0, nil
+    }
+    residual := append([]float64(nil), x...)
+    for j, c := range coords {
+        for i := range residual {
+            residual[i] -= c * basis.At(i, j)
+        }
+    }
+    return math.Min(1, norm(residual)/xNorm), nil
+}
@@ -0,0 +1,128 @@
+package linalg
+
+import (
+    "math"
+    "testing"
+)
+
+func TestMerge(t *testing.T) {
+    old := &Matrix{Rows: 2, Cols: 1, Data: []float64{2, 0}}
+    update := &Matrix{Rows: 2, Cols: 1, Data: []float64{0, 1}}
+    tests := []struct {
+        name   string
+        forget float64
+        rank   int
+        want   [][]float64
+    }{
+        {
+            name:   "no forgetting",
+            forget: 1,
+            rank:   2,
+            want:   [][]float64{{2, 0}, {0, 1}},
+        },
+        {
+            // The old subspace fades below the update.
+            name:   "forgetting",
+            forget: 0.25,
+            rank:   2,
+            want:   [][]float64{{0, 1}, {0.5, 0}},
+        },
+        {
+            name:   "truncated",
+            forget: 0.25,
+            rank:   1,
+            want:   [][]float64{{0, 1}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            merged, err := Merge(old, update, tt.forget, tt.rank)
+            if err != nil {
+                t.Fatal(err)
+            }
+            checkColumns(t, merged, tt.want)
+        })
+    }
+
+    if _, err := Merge(nil, nil, 1, 2); err == nil {
+        t.Error("merged nothing")
+    }
+    if _, err := Merge(old, New(3, 1), 1, 2); err == nil {
+        t.Error("merged subspaces of different dimensions")
+    }
+    if merged, err := Merge(nil, update, 0.5, 2); err != nil {
+        t.Error(err)
+    } else {
+        checkColumns(t, merged, [][]float64{{0, 1}})
+    }
+}
+
+func TestFromGram(t *testing.T) {
+    a := &Matrix{Rows: 3, Cols: 2, Data: []float64{3, 0.5, 1, -1, 0, 0.25}}
+    b := &Matrix{Rows: 3, Cols: 1, Data: []float64{0.5, 0, 2}}
+    sum := Gram(a)
+    for i, v := range Gram(b).Data {
+        sum.Data[i] += v
+    }
+
+    for _, rank := range []int{1, 2, 3} {
+        merged, err := Merge(a, b, 1, rank)
+        if err != nil {
+            t.Fatal(err)
+        }
+        want := make([][]float64, merged.Cols)
+        for j := range want {
+            want[j] = merged.Col(j)
+        }
+        checkColumns(t, FromGram(sum, rank), want)
+    }
+
+    g := Gram(&Matrix{Rows: 2, Cols: 2, Data: []float64{1, 2, 3, 4}})
+    if want := []float64{5, 11, 11, 25}; !equalData(g.Data, want) {
+        t.Errorf("Gram = %v, want %v", g.Data, want)
+    }
+}
+
+func equalData(got, want []float64) bool {
+    if len(got) != len(want) {
+        return false
+    }
+    for i := range got {
+        if math.Abs(got[i]-want[i]) > tolerance {
+            return false
+        }
+    }
+    return true
+}
+
+func TestProject(t *testing.T) {
+    // The x-y plane of a 3-dimensional space.
+    basis := &Matrix{Rows: 3, Cols: 2, Data: []float64{1, 0, 0, 1, 0, 0}}
+    x := []float64{1, 2, 3}
+
+    coords, err := Project(basis, x)
+    if err != nil {
+        t.Fatal(err)
+    }
+    checkValues(t, coords, []float64{1, 2})
+
+    rejection, err := ReconstructionError(basis, x)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := 3 / math.Sqrt(14); math.Abs(rejection-want) > tolerance {
+        t.Errorf("ReconstructionError = %v, want %v", rejection, want)
+    }
+    if rejection, _ := ReconstructionError(basis, []float64{0, 0, 0}); rejection != 0 {
+        t.Errorf("ReconstructionError of zero = %v, want 0", rejection)
+    }
+
+    if _, err := Project(basis, []float64{1, 2}); err == nil {
+        t.Error("projected a vector of another dimension")
+    }
+}
+
+func TestBasis(t *testing.T) {
+    scaled := &Matrix{Rows: 2, Cols: 2, Data: []float64{0, 0.5, 3, 0}}
+    checkColumns(t, Basis(scaled), [][]float64{{0, 1}, {1, 0}})
+}
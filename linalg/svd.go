@@ -0,0 +1,93 @@
+package linalg
+
+import (
+    "math"
+    "sort"
+)
+
+// svdTolerance is the relative size below which a singular value is zero.
+const svdTolerance = 1e-10
+
+// maxSweeps bounds the Jacobi sweeps, which converge quadratically.
+const maxSweeps = 60
+
+// SVD returns the left singular vectors of m with nonzero singular values,
+// as columns, and the singular values in decreasing order. It uses one-sided
+// Jacobi rotations, which are accurate and simple for the small matrices of
+// node metrics.
+func SVD(m *Matrix) (*Matrix, []float64) {
+    w := m.Clone()
+    for sweep := 0; sweep < maxSweeps; sweep++ {
+        rotated := false
+        for p := 0; p < w.Cols; p++ {
+            for q := p + 1; q < w.Cols; q++ {
+                var alpha, beta, gamma float64
+                for i := 0; i < w.Rows; i++ {
+                    wp, wq := w.At(i, p), w.At(i, q)
+                    alpha += wp * wp
+                    beta += wq * wq
+                    gamma += wp * wq
+                }
+                if gamma == 0 || math.Abs(gamma) <= 1e-15*math.Sqrt(alpha*beta) {
+                    continue
+                }
+                rotated = true
+                zeta := (beta - alpha) / (2 * gamma)
+                t := math.Copysign(1, zeta) / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+                c := 1 / math.Sqrt(1+t*t)
+                s := c * t
+                for i := 0; i < w.Rows; i++ {
+                    wp, wq := w.At(i, p), w.At(i, q)
+                    w.Set(i, p, c*wp-s*wq)
+                    w.Set(i, q, s*wp+c*wq)
+                }
+            }
+        }
+        if !rotated {
+            break
+        }
+    }
+
+    sigma := make([]float64, w.Cols)
+    order := make([]int, w.Cols)
+    for j := range sigma {
+        sigma[j] = norm(w.Col(j))
+        order[j] = j
+    }
+    sort.SliceStable(order, func(x, y int) bool { return sigma[order[x]] > sigma[order[y]] })
+
+    rank := 0
+    for rank < len(order) && sigma[order[rank]] > svdTolerance*sigma[order[0]] {
+        rank++
+    }
+    u := New(w.Rows, rank)
+    s := make([]float64, rank)
+    for k, j := range order[:rank] {
+        s[k] = sigma[j]
+        for i := 0; i < w.Rows; i++ {
+            u.Set(i, k, w.At(i, j)/sigma[j])
+        }
+    }
+    return u, s
+}
+
+// TruncatedSVD is SVD keeping at most rank singular vectors.
+func TruncatedSVD(m *Matrix, rank int) (*Matrix, []float64) {
+    u, s := SVD(m)
+    if len(s) <= rank {
+        return u, s
+    }
+    t := New(u.Rows, rank)
+    for i := 0; i < u.Rows; i++ {
+        copy(t.Data[i*rank:(i+1)*rank], u.Data[i*u.Cols:i*u.Cols+rank])
+    }
+    return t, s[:rank]
+}
+
+func norm(x []float64) float64 {
+    var sum float64
+    for _, v := range x {
+        sum += v * v
+    }
+    return math.Sqrt(sum)
+}
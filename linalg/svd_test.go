@@ -0,0 +1,99 @@
+package linalg
+
+import (
+    "math"
+    "testing"
+)
+
+const tolerance = 1e-9
+
+// checkColumns checks that m's columns are want's, each up to its sign.
+func checkColumns(t *testing.T, m *Matrix, want [][]float64) {
+    t.Helper()
+    if m.Cols != len(want) {
+        t.Fatalf("got %d columns, want %d", m.Cols, len(want))
+    }
+    for j, w := range want {
+        col := m.Col(j)
+        sign := 1.0
+        for i := range col {
+            if math.Abs(col[i]) > tolerance {
+                sign = math.Copysign(1, col[i]*w[i])
+                break
+            }
+        }
+        for i := range col {
+            if math.Abs(sign*col[i]-w[i]) > tolerance {
+                t.Errorf("column %d = %v, want %v up to sign", j, col, w)
+                break
+            }
+        }
+    }
+}
+
+func checkValues(t *testing.T, got, want []float64) {
+    t.Helper()
+    if len(got) != len(want) {
+        t.Fatalf("singular values = %v, want %v", got, want)
+    }
+    for i := range got {
+        if math.Abs(got[i]-want[i]) > tolerance {
+            t.Fatalf("singular values = %v, want %v", got, want)
+        }
+    }
+}
+
+func TestSVD(t *testing.T) {
+    r2 := 1 / math.Sqrt2
+    r5 := 1 / math.Sqrt(5)
+    tests := []struct {
+        name  string
+        m     *Matrix
+        u     [][]float64
+        sigma []float64
+    }{
+        {
+            name:  "diagonal",
+            m:     &Matrix{Rows: 3, Cols: 2, Data: []float64{3, 0, 0, 4, 0, 0}},
+            u:     [][]float64{{0, 1, 0}, {1, 0, 0}},
+            sigma: []float64{4, 3},
+        },
+        {
+            // The textbook example: A A^T = [[17, 8], [8, 17]].
+            name:  "wide",
+            m:     &Matrix{Rows: 2, Cols: 3, Data: []float64{3, 2, 2, 2, 3, -2}},
+            u:     [][]float64{{r2, r2}, {r2, -r2}},
+            sigma: []float64{5, 3},
+        },
+        {
+            name:  "rank deficient",
+            m:     &Matrix{Rows: 2, Cols: 2, Data: []float64{1, 2, 2, 4}},
+            u:     [][]float64{{r5, 2 * r5}},
+            sigma: []float64{5},
+        },
+        {
+            name:  "zero",
+            m:     New(2, 2),
+            u:     [][]float64{},
+            sigma: []float64{},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            u, sigma := SVD(tt.m)
+            checkValues(t, sigma, tt.sigma)
+            checkColumns(t, u, tt.u)
+        })
+    }
+}
+
+func TestTruncatedSVD(t *testing.T) {
+    m := &Matrix{Rows: 2, Cols: 3, Data: []float64{3, 2, 2, 2, 3, -2}}
+    u, sigma := TruncatedSVD(m, 1)
+    checkValues(t, sigma, []float64{5})
+    checkColumns(t, u, [][]float64{{1 / math.Sqrt2, 1 / math.Sqrt2}})
+
+    if _, sigma := TruncatedSVD(m, 3); len(sigma) != 2 {
+        t.Errorf("truncating above the rank kept %d values, want 2", len(sigma))
+    }
+}
@@ -127,6 +127,114 @@ var SignalService_ServiceDesc = grpc.ServiceDesc{
 	Metadata: "message/message.proto",
 }
 
+const (
+	SignalQuery_GetSignal_FullMethodName = "/message.SignalQuery/GetSignal"
+)
+
+// SignalQueryClient is the client API for SignalQuery service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SignalQuery is served by node agents that the scheduler polls for their
+// current signal instead of them streaming it.
+type SignalQueryClient interface {
+	GetSignal(ctx context.Context, in *SignalRequest, opts ...grpc.CallOption) (*Signal, error)
+}
+
+type signalQueryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSignalQueryClient(cc grpc.ClientConnInterface) SignalQueryClient {
+	return &signalQueryClient{cc}
+}
+
+func (c *signalQueryClient) GetSignal(ctx context.Context, in *SignalRequest, opts ...grpc.CallOption) (*Signal, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Signal)
+	err := c.cc.Invoke(ctx, SignalQuery_GetSignal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SignalQueryServer is the server API for SignalQuery service.
+// All implementations must embed UnimplementedSignalQueryServer
+// for forward compatibility.
+//
+// SignalQuery is served by node agents that the scheduler polls for their
+// current signal instead of them streaming it.
+type SignalQueryServer interface {
+	GetSignal(context.Context, *SignalRequest) (*Signal, error)
+	mustEmbedUnimplementedSignalQueryServer()
+}
+
+// UnimplementedSignalQueryServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSignalQueryServer struct{}
+
+func (UnimplementedSignalQueryServer) GetSignal(context.Context, *SignalRequest) (*Signal, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSignal not implemented")
+}
+func (UnimplementedSignalQueryServer) mustEmbedUnimplementedSignalQueryServer() {}
+func (UnimplementedSignalQueryServer) testEmbeddedByValue()                     {}
+
+// UnsafeSignalQueryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SignalQueryServer will
+// result in compilation errors.
+type UnsafeSignalQueryServer interface {
+	mustEmbedUnimplementedSignalQueryServer()
+}
+
+func RegisterSignalQueryServer(s grpc.ServiceRegistrar, srv SignalQueryServer) {
+	// If the following call pancis, it indicates UnimplementedSignalQueryServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SignalQuery_ServiceDesc, srv)
+}
+
+func _SignalQuery_GetSignal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignalQueryServer).GetSignal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SignalQuery_GetSignal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignalQueryServer).GetSignal(ctx, req.(*SignalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SignalQuery_ServiceDesc is the grpc.ServiceDesc for SignalQuery service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SignalQuery_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "message.SignalQuery",
+	HandlerType: (*SignalQueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSignal",
+			Handler:    _SignalQuery_GetSignal_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "message/message.proto",
+}
+
 const (
 	AggregateMerge_RequestAggMerge_FullMethodName = "/message.AggregateMerge/RequestAggMerge"
 )
@@ -0,0 +1,5 @@
+package message
+
+// NodeMetadataKey is the gRPC metadata key under which an agent names its
+// node when calling AggregateMerge.
+const NodeMetadataKey = "pronto-node"
@@ -0,0 +1,106 @@
+package plugin
+
+import (
+    "context"
+    "net"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/LucaChot/pronto-framework/linalg"
+    pb "github.com/LucaChot/pronto-framework/message"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+    "k8s.io/utils/clock"
+)
+
+// subspaceAggregator serves AggregateMerge for agents running federated
+// PCA. It keeps the latest scaled subspace of each node and returns the
+// merge of all of them, so the agents converge on a shared view of how
+// nodes normally behave. A node's subspace replaces its previous one
+// however often it merges, and is dropped once it is older than MaxAge.
+type subspaceAggregator struct {
+    pb.UnimplementedAggregateMergeServer
+    cfg   Aggregation
+    clock clock.PassiveClock
+
+    mu    sync.Mutex
+    dims  int
+    nodes map[string]*nodeSubspace
+}
+
+// nodeSubspace is a node's latest subspace, kept as its Gram matrix so that
+// the cluster subspace is one SVD of their sum.
+type nodeSubspace struct {
+    gram    *linalg.Matrix
+    updated time.Time
+}
+
+func newSubspaceAggregator(cfg Aggregation, c clock.PassiveClock) *subspaceAggregator {
+    return &subspaceAggregator{cfg: cfg, clock: c, nodes: make(map[string]*nodeSubspace)}
+}
+
+func (a *subspaceAggregator) RequestAggMerge(ctx context.Context, m *pb.DenseMatrix) (*pb.DenseMatrix, error) {
+    update, err := linalg.FromDense(m)
+    if err != nil {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
+    node := mergingNode(ctx)
+    if node == "" {
+        return nil, status.Error(codes.InvalidArgument, "the merging node is unknown")
+    }
+
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    now := a.clock.Now()
+    a.prune(now)
+    if len(a.nodes) > 0 && update.Rows != a.dims {
+        return nil, status.Errorf(codes.InvalidArgument, "subspace has %d dimensions, the cluster's has %d", update.Rows, a.dims)
+    }
+    a.dims = update.Rows
+    a.nodes[node] = &nodeSubspace{gram: linalg.Gram(update), updated: now}
+    return a.cluster().ToDense(), nil
+}
+
+// prune drops the subspaces of nodes that have not merged within MaxAge.
+func (a *subspaceAggregator) prune(now time.Time) {
+    for node, s := range a.nodes {
+        if now.Sub(s.updated) > a.cfg.MaxAge.Duration {
+            delete(a.nodes, node)
+        }
+    }
+}
+
+// cluster merges the nodes' subspaces, summing their Gram matrices in node
+// order so that the result does not depend on map order.
+func (a *subspaceAggregator) cluster() *linalg.Matrix {
+    names := make([]string, 0, len(a.nodes))
+    for node := range a.nodes {
+        names = append(names, node)
+    }
+    sort.Strings(names)
+    sum := linalg.New(a.dims, a.dims)
+    for _, node := range names {
+        for i, v := range a.nodes[node].gram.Data {
+            sum.Data[i] += v
+        }
+    }
+    return linalg.FromGram(sum, a.cfg.Rank)
+}
+
+// mergingNode names the node calling AggregateMerge: the node in the call's
+// metadata, or else the host of the peer, as each agent merges from its own
+// pod.
+func mergingNode(ctx context.Context) string {
+    if md, ok := metadata.FromIncomingContext(ctx); ok {
+        if v := md.Get(pb.NodeMetadataKey); len(v) > 0 && v[0] != "" {
+            return v[0]
+        }
+    }
+    addr := peerAddress(ctx)
+    if host, _, err := net.SplitHostPort(addr); err == nil {
+        return host
+    }
+    return addr
+}
@@ -0,0 +1,176 @@
+package plugin
+
+import (
+    "context"
+    "math"
+    "net"
+    "testing"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/status"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/utils/clock"
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+func newTestAggregator(c clock.PassiveClock) *subspaceAggregator {
+    return newSubspaceAggregator(Aggregation{Rank: 2, MaxAge: metav1.Duration{Duration: time.Minute}}, c)
+}
+
+// fromNode is the context of an AggregateMerge call by the node's agent.
+func fromNode(node string) context.Context {
+    return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pb.NodeMetadataKey, node))
+}
+
+func vector(data ...float64) *pb.DenseMatrix {
+    return &pb.DenseMatrix{Rows: int64(len(data)), Cols: 1, Data: data}
+}
+
+// checkSubspace checks a merged subspace against want, row-major, up to the
+// sign of each column.
+func checkSubspace(t *testing.T, got *pb.DenseMatrix, rows, cols int64, want []float64) {
+    t.Helper()
+    if got.GetRows() != rows || got.GetCols() != cols {
+        t.Fatalf("merged subspace is %dx%d, want %dx%d", got.GetRows(), got.GetCols(), rows, cols)
+    }
+    for i, v := range got.GetData() {
+        if math.Abs(math.Abs(v)-math.Abs(want[i])) > 1e-9 {
+            t.Fatalf("merged subspace = %v, want %v up to column signs", got.GetData(), want)
+        }
+    }
+}
+
+func TestSubspaceAggregatorMerges(t *testing.T) {
+    a := newTestAggregator(clock.RealClock{})
+
+    // Two nodes whose metrics vary along different axes of a 3-dimensional
+    // feature space.
+    if _, err := a.RequestAggMerge(fromNode("a"), vector(2, 0, 0)); err != nil {
+        t.Fatal(err)
+    }
+    merged, err := a.RequestAggMerge(fromNode("b"), vector(0, 1, 0))
+    if err != nil {
+        t.Fatal(err)
+    }
+    // The columns are the axes scaled by each node's singular value,
+    // largest first.
+    checkSubspace(t, merged, 3, 2, []float64{2, 0, 0, 1, 0, 0})
+}
+
+func TestSubspaceAggregatorKeepsLatestPerNode(t *testing.T) {
+    a := newTestAggregator(clock.RealClock{})
+
+    // Merging the same subspace again does not add to its weight.
+    for i := 0; i < 10; i++ {
+        if _, err := a.RequestAggMerge(fromNode("a"), vector(2, 0, 0)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    merged, err := a.RequestAggMerge(fromNode("b"), vector(0, 1, 0))
+    if err != nil {
+        t.Fatal(err)
+    }
+    checkSubspace(t, merged, 3, 2, []float64{2, 0, 0, 1, 0, 0})
+
+    // Nor does merging often fade the other nodes' subspaces.
+    for i := 0; i < 10; i++ {
+        if merged, err = a.RequestAggMerge(fromNode("b"), vector(0, 1, 0)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    checkSubspace(t, merged, 3, 2, []float64{2, 0, 0, 1, 0, 0})
+
+    // A node's new subspace replaces its old one.
+    merged, err = a.RequestAggMerge(fromNode("a"), vector(0, 0, 3))
+    if err != nil {
+        t.Fatal(err)
+    }
+    checkSubspace(t, merged, 3, 2, []float64{0, 0, 0, 1, 3, 0})
+}
+
+func TestSubspaceAggregatorDropsStaleNodes(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    clk := clocktesting.NewFakePassiveClock(start)
+    a := newTestAggregator(clk)
+
+    if _, err := a.RequestAggMerge(fromNode("gone"), vector(2, 0, 0)); err != nil {
+        t.Fatal(err)
+    }
+    clk.SetTime(start.Add(30 * time.Second))
+    merged, err := a.RequestAggMerge(fromNode("b"), vector(0, 1, 0))
+    if err != nil {
+        t.Fatal(err)
+    }
+    checkSubspace(t, merged, 3, 2, []float64{2, 0, 0, 1, 0, 0})
+
+    clk.SetTime(start.Add(90 * time.Second))
+    if merged, err = a.RequestAggMerge(fromNode("b"), vector(0, 1, 0)); err != nil {
+        t.Fatal(err)
+    }
+    checkSubspace(t, merged, 3, 1, []float64{0, 1, 0})
+
+    // Once every node has gone, the cluster can change dimension.
+    clk.SetTime(start.Add(time.Hour))
+    if merged, err = a.RequestAggMerge(fromNode("c"), vector(0, 4)); err != nil {
+        t.Fatal(err)
+    }
+    checkSubspace(t, merged, 2, 1, []float64{0, 4})
+}
+
+func TestMergingNode(t *testing.T) {
+    fromPeer := func(ctx context.Context, address string) context.Context {
+        addr, err := net.ResolveTCPAddr("tcp", address)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return peer.NewContext(ctx, &peer.Peer{Addr: addr})
+    }
+    for _, tc := range []struct {
+        name string
+        ctx  context.Context
+        want string
+    }{
+        {name: "metadata", ctx: fromPeer(fromNode("node-1"), "10.0.0.1:40000"), want: "node-1"},
+        {name: "peer host", ctx: fromPeer(context.Background(), "10.0.0.1:40000"), want: "10.0.0.1"},
+        {name: "unknown", ctx: context.Background(), want: ""},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            if got := mergingNode(tc.ctx); got != tc.want {
+                t.Errorf("mergingNode() = %q, want %q", got, tc.want)
+            }
+        })
+    }
+}
+
+func TestSubspaceAggregatorRejects(t *testing.T) {
+    tests := []struct {
+        name string
+        ctx  context.Context
+        m    *pb.DenseMatrix
+    }{
+        {name: "missing elements", ctx: fromNode("b"), m: &pb.DenseMatrix{Rows: 3, Cols: 2, Data: []float64{1, 2, 3}}},
+        {name: "other dimension", ctx: fromNode("b"), m: vector(1, 0)},
+        {name: "overflowing dimensions", ctx: fromNode("b"), m: &pb.DenseMatrix{Rows: 1 << 32, Cols: 1 << 32}},
+        {name: "NaN", ctx: fromNode("b"), m: vector(math.NaN(), 0, 0)},
+        {name: "unknown node", ctx: context.Background(), m: vector(0, 1, 0)},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a := newTestAggregator(clock.RealClock{})
+            if _, err := a.RequestAggMerge(fromNode("a"), vector(1, 0, 0)); err != nil {
+                t.Fatal(err)
+            }
+            _, err := a.RequestAggMerge(tt.ctx, tt.m)
+            if status.Code(err) != codes.InvalidArgument {
+                t.Errorf("RequestAggMerge error = %v, want InvalidArgument", err)
+            }
+            if len(a.nodes) != 1 || a.dims != 3 {
+                t.Errorf("rejected merge changed the cluster to %d nodes of %d dimensions", len(a.nodes), a.dims)
+            }
+        })
+    }
+}
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "github.com/go-logr/logr"
+    v1 "k8s.io/api/core/v1"
+    "k8s.io/client-go/tools/cache"
+)
+
+const annotationSourceName = "annotations"
+
+// annotationSource reads signals from annotations on the Node objects, for
+// agents that already label their node instead of talking to the scheduler.
+type annotationSource struct {
+    state    *prontoState
+    informer cache.SharedIndexInformer
+    keys     AnnotationSource
+    logger   logr.Logger
+}
+
+func newAnnotationSource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    return &annotationSource{
+        state:    &pl.prontoState,
+        informer: pl.handle.SharedInformerFactory().Core().V1().Nodes().Informer(),
+        keys:     pl.args.AnnotationSource,
+        logger:   logger,
+    }, nil
+}
+
+func (src *annotationSource) Name() string { return annotationSourceName }
+
+// Start registers with the scheduler's node informer, which the scheduler
+// starts once every plugin has been created.
+func (src *annotationSource) Start(ctx context.Context) error {
+    _, err := src.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+        AddFunc: func(obj interface{}) {
+            src.onNode(nil, obj.(*v1.Node))
+        },
+        UpdateFunc: func(oldObj, newObj interface{}) {
+            src.onNode(oldObj.(*v1.Node), newObj.(*v1.Node))
+        },
+    })
+    return err
+}
+
+func (src *annotationSource) onNode(oldNode, node *v1.Node) {
+    if oldNode != nil && !src.annotationsChanged(oldNode, node) {
+        return
+    }
+    if _, ok := node.Annotations[src.keys.SignalAnnotation]; !ok {
+        return
+    }
+
+    m, err := src.read(node)
+    if err != nil {
+        signalSamples.WithLabelValues(sampleInvalidValue).Inc()
+        src.logger.Error(err, "Reading signal annotations", "node", node.Name)
+        return
+    }
+    at, err := src.takenAt(node)
+    if err != nil {
+        signalSamples.WithLabelValues(sampleInvalidValue).Inc()
+        src.logger.Error(err, "Reading signal annotations", "node", node.Name)
+        return
+    }
+    if reason := validateSignal(node.Name, m); reason != "" {
+        signalSamples.WithLabelValues(reason).Inc()
+        src.logger.Error(nil, "Dropping signal annotations", "node", node.Name, "reason", reason)
+        return
+    }
+    signalSamples.WithLabelValues(sampleAccepted).Inc()
+    src.state.ingestAt(annotationSourceName, node.Name, at, WithCapacity(m.Capacity),
+        WithSignal(m.Signal),
+        WithOverprovision(m.Overprovision))
+}
+
+// read parses the signal annotations of a Node.
+func (src *annotationSource) read(node *v1.Node) (*pb.Signal, error) {
+    signal, err := src.extract(node, src.keys.SignalAnnotation)
+    if err != nil {
+        return nil, err
+    }
+    capacity, err := src.extract(node, src.keys.CapacityAnnotation)
+    if err != nil {
+        return nil, err
+    }
+    overprovision, err := src.extractOptional(node, src.keys.OverprovisionAnnotation)
+    if err != nil {
+        return nil, err
+    }
+    return &pb.Signal{Signal: signal, Capacity: capacity, Overprovision: overprovision}, nil
+}
+
+// takenAt returns when the node's signal was taken: the time in its
+// TimestampAnnotation, or now if it has none. A time ahead of the
+// scheduler's clock is taken as now, so clock skew cannot make a signal
+// younger than fresh.
+func (src *annotationSource) takenAt(node *v1.Node) (time.Time, error) {
+    now := src.state.clock.Now()
+    val, ok := node.Annotations[src.keys.TimestampAnnotation]
+    if src.keys.TimestampAnnotation == "" || !ok {
+        return now, nil
+    }
+    at, err := time.Parse(time.RFC3339Nano, val)
+    if err != nil {
+        return time.Time{}, fmt.Errorf("parsing annotation %s: %w", src.keys.TimestampAnnotation, err)
+    }
+    if at.After(now) {
+        return now, nil
+    }
+    return at, nil
+}
+
+func (src *annotationSource) annotationsChanged(oldNode, node *v1.Node) bool {
+    for _, key := range []string{src.keys.SignalAnnotation, src.keys.CapacityAnnotation, src.keys.OverprovisionAnnotation, src.keys.TimestampAnnotation} {
+        if oldNode.Annotations[key] != node.Annotations[key] {
+            return true
+        }
+    }
+    return false
+}
+
+// extract reads a numeric annotation from a Node.
+func (src *annotationSource) extract(node *v1.Node, key string) (float64, error) {
+    val, ok := node.Annotations[key]
+    if !ok {
+        return 0, fmt.Errorf("annotation %s is missing", key)
+    }
+    f, err := strconv.ParseFloat(val, 64)
+    if err != nil {
+        return 0, fmt.Errorf("parsing annotation %s: %w", key, err)
+    }
+    return f, nil
+}
+
+// extractOptional is extract, returning 0 if the annotation is missing.
+func (src *annotationSource) extractOptional(node *v1.Node, key string) (float64, error) {
+    if _, ok := node.Annotations[key]; !ok {
+        return 0, nil
+    }
+    return src.extract(node, key)
+}
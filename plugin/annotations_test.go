@@ -0,0 +1,157 @@
+package plugin
+
+import (
+    "testing"
+    "time"
+
+    "github.com/go-logr/logr"
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestAnnotationSourceValidates(t *testing.T) {
+    keys := AnnotationSource{
+        SignalAnnotation:        "pronto/signal",
+        CapacityAnnotation:      "pronto/capacity",
+        OverprovisionAnnotation: "pronto/overprovision",
+    }
+    tests := []struct {
+        name        string
+        annotations map[string]string
+        ingested    bool
+    }{
+        {
+            name:        "valid",
+            annotations: map[string]string{"pronto/signal": "0.5", "pronto/capacity": "2"},
+            ingested:    true,
+        },
+        {
+            name:        "NaN signal",
+            annotations: map[string]string{"pronto/signal": "NaN", "pronto/capacity": "2"},
+        },
+        {
+            name:        "infinite capacity",
+            annotations: map[string]string{"pronto/signal": "0.5", "pronto/capacity": "+Inf"},
+        },
+        {
+            name:        "infinite overprovision",
+            annotations: map[string]string{"pronto/signal": "0.5", "pronto/capacity": "2", "pronto/overprovision": "-Inf"},
+        },
+        {
+            name:        "missing capacity",
+            annotations: map[string]string{"pronto/signal": "0.5"},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            src := &annotationSource{
+                state:  newTestState(),
+                keys:   keys,
+                logger: logr.Discard(),
+            }
+            src.onNode(nil, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n", Annotations: tt.annotations}})
+            if got := src.state.GetHost("n") != nil; got != tt.ingested {
+                t.Errorf("ingested = %v, want %v", got, tt.ingested)
+            }
+        })
+    }
+}
+
+func TestAnnotationSourceTimestamp(t *testing.T) {
+    now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+    keys := AnnotationSource{
+        SignalAnnotation:    "pronto/signal",
+        CapacityAnnotation:  "pronto/capacity",
+        TimestampAnnotation: "pronto/signal-time",
+    }
+    tests := []struct {
+        name       string
+        keys       AnnotationSource
+        timestamp  string
+        ingested   bool
+        lastUpdate time.Time
+    }{
+        {
+            name:       "written before it was seen",
+            keys:       keys,
+            timestamp:  "2024-01-01T11:58:30Z",
+            ingested:   true,
+            lastUpdate: now.Add(-90 * time.Second),
+        },
+        {
+            name:       "fractional seconds",
+            keys:       keys,
+            timestamp:  "2024-01-01T11:59:59.5Z",
+            ingested:   true,
+            lastUpdate: now.Add(-500 * time.Millisecond),
+        },
+        {
+            name:       "missing timestamp is aged from delivery",
+            keys:       keys,
+            ingested:   true,
+            lastUpdate: now,
+        },
+        {
+            name:       "timestamp ahead of the scheduler",
+            keys:       keys,
+            timestamp:  "2024-01-01T12:05:00Z",
+            ingested:   true,
+            lastUpdate: now,
+        },
+        {
+            name:      "invalid timestamp",
+            keys:      keys,
+            timestamp: "yesterday",
+        },
+        {
+            name:       "timestamp annotation unset",
+            keys:       AnnotationSource{SignalAnnotation: "pronto/signal", CapacityAnnotation: "pronto/capacity"},
+            timestamp:  "2024-01-01T11:58:30Z",
+            ingested:   true,
+            lastUpdate: now,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            state := newTestState()
+            state.clock = clocktesting.NewFakePassiveClock(now)
+            src := &annotationSource{state: state, keys: tt.keys, logger: logr.Discard()}
+            annotations := map[string]string{"pronto/signal": "0.5", "pronto/capacity": "2"}
+            if tt.timestamp != "" {
+                annotations["pronto/signal-time"] = tt.timestamp
+            }
+            src.onNode(nil, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n", Annotations: annotations}})
+            hi := state.GetHost("n")
+            if got := hi != nil; got != tt.ingested {
+                t.Fatalf("ingested = %v, want %v", got, tt.ingested)
+            }
+            if hi != nil && !hi.LastUpdate.Equal(tt.lastUpdate) {
+                t.Errorf("LastUpdate = %v, want %v", hi.LastUpdate, tt.lastUpdate)
+            }
+        })
+    }
+}
+
+func TestAnnotationSourceTimestampOnlyUpdate(t *testing.T) {
+    now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+    state := newTestState()
+    state.clock = clocktesting.NewFakePassiveClock(now)
+    src := &annotationSource{state: state, keys: AnnotationSource{
+        SignalAnnotation:    "pronto/signal",
+        CapacityAnnotation:  "pronto/capacity",
+        TimestampAnnotation: "pronto/signal-time",
+    }, logger: logr.Discard()}
+    node := func(timestamp string) *v1.Node {
+        return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n", Annotations: map[string]string{
+            "pronto/signal": "0.5", "pronto/capacity": "2", "pronto/signal-time": timestamp}}}
+    }
+
+    // An agent that re-reports the same values refreshes the signal.
+    old := node("2024-01-01T11:58:00Z")
+    src.onNode(nil, old)
+    src.onNode(old, node("2024-01-01T11:59:00Z"))
+    if got, want := state.GetHost("n").LastUpdate, now.Add(-time.Minute); !got.Equal(want) {
+        t.Errorf("LastUpdate = %v, want %v", got, want)
+    }
+}
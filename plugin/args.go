@@ -0,0 +1,623 @@
+package plugin
+
+import (
+    "fmt"
+    "net"
+    "strings"
+    "time"
+
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    tracingapi "k8s.io/component-base/tracing/api/v1"
+    frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+)
+
+// ScoringStrategyType selects how Score ranks the nodes that passed Filter.
+type ScoringStrategyType string
+
+const (
+    // LeastLoaded spreads pods onto the nodes with the most free capacity.
+    LeastLoaded ScoringStrategyType = "LeastLoaded"
+    // MostLoaded bin-packs pods onto the nodes with the least free capacity,
+    // keeping the remaining nodes free.
+    MostLoaded ScoringStrategyType = "MostLoaded"
+    // Balanced favours nodes whose signal is closest to TargetUtilization.
+    Balanced ScoringStrategyType = "Balanced"
+    // Weighted scores a node as a linear combination of its signal, free
+    // capacity and free overprovision.
+    Weighted ScoringStrategyType = "Weighted"
+    // LowerConfidenceBound scores a node by the free capacity it has with
+    // high confidence: its estimated capacity minus Confidence standard
+    // deviations, less the pods reserved on it.
+    LowerConfidenceBound ScoringStrategyType = "LowerConfidenceBound"
+    // ThompsonSampling scores a node by a capacity drawn from its estimated
+    // distribution, less the pods reserved on it.
+    ThompsonSampling ScoringStrategyType = "ThompsonSampling"
+)
+
+// ScoringWeights are the coefficients used by the Weighted strategy.
+type ScoringWeights struct {
+    Signal        float64 `json:"signal"`
+    Capacity      float64 `json:"capacity"`
+    Overprovision float64 `json:"overprovision"`
+}
+
+// ScoringStrategy configures Score.
+type ScoringStrategy struct {
+    Type ScoringStrategyType `json:"type,omitempty"`
+    // TargetUtilization is the signal the Balanced strategy aims for, in [0, 1].
+    TargetUtilization float64 `json:"targetUtilization,omitempty"`
+    // Weights are only used by the Weighted strategy.
+    Weights *ScoringWeights `json:"weights,omitempty"`
+    // Confidence is the number of standard deviations subtracted by the
+    // LowerConfidenceBound strategy.
+    Confidence float64 `json:"confidence,omitempty"`
+    // DriftVariance is added to a node's capacity variance for every second
+    // since its last signal, so stale nodes look riskier to the
+    // LowerConfidenceBound and ThompsonSampling strategies.
+    DriftVariance float64 `json:"driftVariance,omitempty"`
+}
+
+// ScoreNormalizerType selects how NormalizeScore rescales the raw scores.
+type ScoreNormalizerType string
+
+const (
+    // MinMaxNormalizer linearly maps the lowest score to MinNodeScore and the
+    // highest to MaxNodeScore.
+    MinMaxNormalizer ScoreNormalizerType = "MinMax"
+    // RankNormalizer spaces nodes evenly across the score range by rank.
+    RankNormalizer ScoreNormalizerType = "Rank"
+    // ZScoreNormalizer maps standardised scores, clipped to ZScoreClip
+    // standard deviations, onto the score range.
+    ZScoreNormalizer ScoreNormalizerType = "ZScore"
+    // LogNormalizer applies min-max scaling to the logarithm of the scores.
+    LogNormalizer ScoreNormalizerType = "Log"
+    // AbsoluteNormalizer does not rescale, it only clamps the raw scores.
+    AbsoluteNormalizer ScoreNormalizerType = "Absolute"
+)
+
+// ScoreNormalizer configures NormalizeScore.
+type ScoreNormalizer struct {
+    Type ScoreNormalizerType `json:"type,omitempty"`
+    // ZScoreClip is the number of standard deviations kept by the ZScore
+    // normalizer.
+    ZScoreClip float64 `json:"zScoreClip,omitempty"`
+}
+
+// Sampling configures power-of-k-choices placement.
+type Sampling struct {
+    // K is the number of filtered nodes sampled and scored for each pod.
+    // Zero scores every node.
+    K int `json:"k,omitempty"`
+    // WeightByCapacity samples nodes with probability proportional to their
+    // free capacity instead of uniformly.
+    WeightByCapacity bool `json:"weightByCapacity,omitempty"`
+}
+
+// Shadow configures shadow mode.
+type Shadow struct {
+    // Enabled evaluates every pod bound by another scheduler profile as if
+    // Pronto had scheduled it, recording whether Pronto agrees with the
+    // choice. Bindings are never affected.
+    Enabled bool `json:"enabled,omitempty"`
+}
+
+// Debug configures the debug HTTP endpoint.
+type Debug struct {
+    // Address the endpoint listens on, e.g. ":10260". Empty disables it.
+    Address string `json:"address,omitempty"`
+    // TokenFile holds the bearer token clients must present.
+    TokenFile string `json:"tokenFile,omitempty"`
+    // CertFile and KeyFile serve the endpoint over TLS when both are set.
+    // They are required unless Address is a loopback address, so the token
+    // never crosses the network in cleartext.
+    CertFile string `json:"certFile,omitempty"`
+    KeyFile  string `json:"keyFile,omitempty"`
+}
+
+// Audit configures the decision audit log.
+type Audit struct {
+    // Path of the JSON-lines audit log. Empty disables it.
+    Path string `json:"path,omitempty"`
+    // MaxSizeMB is the size at which the log is rotated.
+    MaxSizeMB int `json:"maxSizeMB,omitempty"`
+    // MaxBackups is the number of rotated files kept. Zero keeps them all.
+    MaxBackups int `json:"maxBackups,omitempty"`
+    // MaxAgeDays is the age after which rotated files are removed. Zero
+    // keeps them regardless of age.
+    MaxAgeDays int  `json:"maxAgeDays,omitempty"`
+    Compress   bool `json:"compress,omitempty"`
+}
+
+// Publish configures publishing Pronto's view of each node to the node's
+// annotations.
+type Publish struct {
+    // Interval between publishing rounds. Zero disables publishing.
+    Interval metav1.Duration `json:"interval,omitempty"`
+    // MinChange is the relative change in a node's signal or capacity that
+    // is worth publishing. A change in reservations is always published.
+    MinChange float64 `json:"minChange,omitempty"`
+    // QPS and Burst rate-limit the node patches.
+    QPS   float64 `json:"qps,omitempty"`
+    Burst int     `json:"burst,omitempty"`
+}
+
+// SourceConfig enables a signal source.
+type SourceConfig struct {
+    // Name of the source: grpc, crd, annotations, scrape, remoteWrite, orca,
+    // metrics or replay.
+    Name string `json:"name"`
+    // MaxAge is how long a sample from this source keeps precedence over
+    // the sources listed after it. Zero keeps it forever.
+    MaxAge metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// CRDSource configures the crd signal source, which reads ProntoSignal
+// objects.
+type CRDSource struct {
+    // ResyncPeriod of the ProntoSignal informer.
+    ResyncPeriod metav1.Duration `json:"resyncPeriod,omitempty"`
+}
+
+// AnnotationSource configures the annotations signal source, which reads
+// signals from annotations on the Node objects.
+type AnnotationSource struct {
+    SignalAnnotation        string `json:"signalAnnotation,omitempty"`
+    CapacityAnnotation      string `json:"capacityAnnotation,omitempty"`
+    OverprovisionAnnotation string `json:"overprovisionAnnotation,omitempty"`
+    // TimestampAnnotation holds the RFC 3339 time the agent took the
+    // signal, which the node's signal age counts from. A node without it is
+    // aged from when the scheduler's informer delivered the update, which
+    // may be well after the annotation was written, e.g. after a restart.
+    TimestampAnnotation string `json:"timestampAnnotation,omitempty"`
+}
+
+// ScrapeProtocol selects how the scrape source polls node agents.
+type ScrapeProtocol string
+
+const (
+    // ScrapeGRPC calls the agent's SignalQuery service, which pronto-agent
+    // serves on its --query-address. It is the default.
+    ScrapeGRPC ScrapeProtocol = "GRPC"
+    // ScrapeHTTP fetches the agent's Signal as JSON from Path, for agents
+    // other than pronto-agent.
+    ScrapeHTTP ScrapeProtocol = "HTTP"
+)
+
+// ScrapeSource configures the scrape signal source, which polls node agents
+// for their signal.
+type ScrapeSource struct {
+    Protocol ScrapeProtocol `json:"protocol,omitempty"`
+    // PodSelector selects the agent pods, polled on their pod IP, in
+    // Namespace or in every namespace if it is empty. Unset polls every
+    // node on its InternalIP instead.
+    PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+    Namespace   string                `json:"namespace,omitempty"`
+    Port        int32                 `json:"port,omitempty"`
+    // Path is the HTTP path serving the signal. It is only used with the
+    // HTTP protocol.
+    Path     string          `json:"path,omitempty"`
+    Interval metav1.Duration `json:"interval,omitempty"`
+    // Jitter delays each poll by up to this fraction of Interval.
+    Jitter  float64         `json:"jitter,omitempty"`
+    Timeout metav1.Duration `json:"timeout,omitempty"`
+    // Concurrency is the number of polls in flight.
+    Concurrency int `json:"concurrency,omitempty"`
+    // MaxBackoff caps the delay before polling an agent that keeps failing,
+    // which doubles from Interval with each failure.
+    MaxBackoff metav1.Duration `json:"maxBackoff,omitempty"`
+}
+
+// RemoteWriteSource configures the remoteWrite signal source, which accepts
+// Prometheus remote-write requests and maps each node's series onto its
+// signal.
+type RemoteWriteSource struct {
+    // Address and Path the remote-write endpoint is served on.
+    Address string `json:"address,omitempty"`
+    Path    string `json:"path,omitempty"`
+    // NodeLabel is the series label naming the node.
+    NodeLabel string `json:"nodeLabel,omitempty"`
+    // Signal, Capacity and Overprovision are expressions over a node's
+    // series, such as "1 - rate(node_pressure_cpu_waiting_seconds_total)".
+    // They support numbers, selectors with label matchers, + - * /,
+    // parentheses, rate(selector), min(...) and max(...). Overprovision
+    // defaults to 0.
+    Signal        string `json:"signal,omitempty"`
+    Capacity      string `json:"capacity,omitempty"`
+    Overprovision string `json:"overprovision,omitempty"`
+    // SeriesTTL drops series that have not been written for this long.
+    SeriesTTL metav1.Duration `json:"seriesTTL,omitempty"`
+}
+
+// OrcaMetric maps a field of an ORCA load report onto a HostInfo field as
+// Offset + Scale*value.
+type OrcaMetric struct {
+    // Key is cpu_utilization, mem_utilization, application_utilization,
+    // utilization.<name> or named_metrics.<name>. Empty maps every report
+    // to Offset.
+    Key    string  `json:"key,omitempty"`
+    Scale  float64 `json:"scale,omitempty"`
+    Offset float64 `json:"offset,omitempty"`
+}
+
+// OrcaSource configures the orca signal source, which streams ORCA load
+// reports from a backend serving the OpenRcaService on every node, such as
+// a gRPC server with ORCA out-of-band reporting. pronto-agent does not
+// serve it.
+type OrcaSource struct {
+    // PodSelector selects the backend pods, streamed from on their pod IP,
+    // in Namespace or in every namespace if it is empty. Unset streams from
+    // every node on its InternalIP instead.
+    PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+    Namespace   string                `json:"namespace,omitempty"`
+    Port        int32                 `json:"port,omitempty"`
+    // ReportInterval is the interval requested from the agents.
+    ReportInterval metav1.Duration `json:"reportInterval,omitempty"`
+    // ResyncPeriod is how often the agents are rediscovered.
+    ResyncPeriod metav1.Duration `json:"resyncPeriod,omitempty"`
+    // MaxBackoff caps the delay before reconnecting to an agent.
+    MaxBackoff    metav1.Duration `json:"maxBackoff,omitempty"`
+    Signal        OrcaMetric      `json:"signal,omitempty"`
+    Capacity      OrcaMetric      `json:"capacity,omitempty"`
+    Overprovision OrcaMetric      `json:"overprovision,omitempty"`
+}
+
+// MetricsSource configures the metrics signal source, a low-confidence
+// fallback that estimates a node's signal from the Metrics API.
+type MetricsSource struct {
+    // Interval between polls of the Metrics API.
+    Interval metav1.Duration `json:"interval,omitempty"`
+    // PodCPU and PodMemory are the resources assumed per pod when estimating
+    // how many pods fit in a node's unused allocatable resources.
+    PodCPU    resource.Quantity `json:"podCPU,omitempty"`
+    PodMemory resource.Quantity `json:"podMemory,omitempty"`
+}
+
+// Aggregation configures the AggregateMerge service, served alongside the
+// grpc source, which merges the subspaces of agents running federated PCA.
+type Aggregation struct {
+    // Rank is the dimension of the cluster subspace.
+    Rank int `json:"rank,omitempty"`
+    // MaxAge drops the subspace of a node that has not merged for this
+    // long, e.g. because it left the cluster. Each node's latest subspace
+    // counts once, and its agent already forgets old samples.
+    MaxAge metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// Record configures recording the signals received by the grpc source.
+type Record struct {
+    // Path of the recording, appended to if it exists. Empty disables
+    // recording. Replay it with cmd/pronto-replay or the replay source.
+    Path string `json:"path,omitempty"`
+}
+
+// ReplaySource configures the replay signal source, which ingests a
+// recording made with Record instead of listening to agents.
+type ReplaySource struct {
+    // Path of the recording.
+    Path string `json:"path,omitempty"`
+    // Speed is the replay rate relative to the recording; 0 replays as fast
+    // as possible.
+    Speed float64 `json:"speed,omitempty"`
+}
+
+// ProntoArgs holds the arguments used to configure the Pronto plugin. They
+// are read from the plugin's pluginConfig entry in the scheduler profile.
+type ProntoArgs struct {
+    ScoringStrategy ScoringStrategy `json:"scoringStrategy,omitempty"`
+    ScoreNormalizer ScoreNormalizer `json:"scoreNormalizer,omitempty"`
+    Sampling        Sampling        `json:"sampling,omitempty"`
+    Shadow          Shadow          `json:"shadow,omitempty"`
+    Debug           Debug           `json:"debug,omitempty"`
+    Audit           Audit           `json:"audit,omitempty"`
+    Publish         Publish         `json:"publish,omitempty"`
+    // Sources are the enabled signal sources, in order of precedence. A
+    // node's signal comes from the first source with a fresh sample for it.
+    Sources           []SourceConfig    `json:"sources,omitempty"`
+    CRDSource         CRDSource         `json:"crdSource,omitempty"`
+    AnnotationSource  AnnotationSource  `json:"annotationSource,omitempty"`
+    ScrapeSource      ScrapeSource      `json:"scrapeSource,omitempty"`
+    RemoteWriteSource RemoteWriteSource `json:"remoteWriteSource,omitempty"`
+    OrcaSource        OrcaSource        `json:"orcaSource,omitempty"`
+    MetricsSource     MetricsSource     `json:"metricsSource,omitempty"`
+    Aggregation       Aggregation       `json:"aggregation,omitempty"`
+    Record            Record            `json:"record,omitempty"`
+    ReplaySource      ReplaySource      `json:"replaySource,omitempty"`
+    // Tracing exports OpenTelemetry spans for signal ingestion and the
+    // scheduling cycle to an OTLP collector. Unset disables exporting.
+    Tracing *tracingapi.TracingConfiguration `json:"tracing,omitempty"`
+    // SignalSmoothing is the weight, in (0, 1], given to each new capacity
+    // sample by the per-node estimator.
+    SignalSmoothing float64 `json:"signalSmoothing,omitempty"`
+    // MaxSignalAge rejects nodes whose last signal is older than this in
+    // Filter. Zero accepts signals of any age.
+    MaxSignalAge metav1.Duration `json:"maxSignalAge,omitempty"`
+    // LowConfidenceMargin is subtracted from the capacity of nodes whose
+    // last signal is low-confidence, such as a Metrics API estimate, before
+    // Filter and Score judge them.
+    LowConfidenceMargin float64 `json:"lowConfidenceMargin,omitempty"`
+    // Seed seeds the plugin's random choices. Zero seeds from the clock.
+    Seed int64 `json:"seed,omitempty"`
+}
+
+func defaultProntoArgs() *ProntoArgs {
+    return &ProntoArgs{
+        ScoringStrategy: ScoringStrategy{
+            Type:              LeastLoaded,
+            TargetUtilization: 0.7,
+            Confidence:        1,
+            DriftVariance:     0.01,
+        },
+        ScoreNormalizer: ScoreNormalizer{
+            Type:       MinMaxNormalizer,
+            ZScoreClip: 3,
+        },
+        SignalSmoothing:     0.3,
+        LowConfidenceMargin: podCost,
+        Audit: Audit{
+            MaxSizeMB:  100,
+            MaxBackups: 5,
+        },
+        Sources: []SourceConfig{{Name: grpcSourceName}},
+        AnnotationSource: AnnotationSource{
+            SignalAnnotation:        "pronto/signal",
+            CapacityAnnotation:      "pronto/pod-cost",
+            OverprovisionAnnotation: "pronto/overprovision",
+            TimestampAnnotation:     "pronto/signal-time",
+        },
+        ScrapeSource: ScrapeSource{
+            Protocol:    ScrapeGRPC,
+            Port:        50052,
+            Path:        "/signal",
+            Interval:    metav1.Duration{Duration: 5 * time.Second},
+            Jitter:      0.5,
+            Timeout:     metav1.Duration{Duration: 2 * time.Second},
+            Concurrency: 16,
+            MaxBackoff:  metav1.Duration{Duration: 2 * time.Minute},
+        },
+        RemoteWriteSource: RemoteWriteSource{
+            Address:   ":9201",
+            Path:      "/api/v1/write",
+            NodeLabel: "node",
+            SeriesTTL: metav1.Duration{Duration: 5 * time.Minute},
+        },
+        OrcaSource: OrcaSource{
+            Port:           50053,
+            ReportInterval: metav1.Duration{Duration: 5 * time.Second},
+            ResyncPeriod:   metav1.Duration{Duration: 30 * time.Second},
+            MaxBackoff:     metav1.Duration{Duration: 2 * time.Minute},
+            Signal:         OrcaMetric{Key: orcaCPUUtilization, Scale: 1},
+            Capacity:       OrcaMetric{Key: orcaNamedMetricPrefix + "capacity", Scale: 1},
+            Overprovision:  OrcaMetric{Scale: 1},
+        },
+        ReplaySource: ReplaySource{Speed: 1},
+        Aggregation: Aggregation{
+            Rank:   2,
+            MaxAge: metav1.Duration{Duration: 5 * time.Minute},
+        },
+        MetricsSource: MetricsSource{
+            Interval:  metav1.Duration{Duration: 30 * time.Second},
+            PodCPU:    resource.MustParse("500m"),
+            PodMemory: resource.MustParse("512Mi"),
+        },
+        Publish: Publish{
+            MinChange: 0.05,
+            QPS:       5,
+            Burst:     10,
+        },
+    }
+}
+
+func decodeProntoArgs(obj runtime.Object) (*ProntoArgs, error) {
+    args := defaultProntoArgs()
+    if err := frameworkruntime.DecodeInto(obj, args); err != nil {
+        return nil, fmt.Errorf("decoding %s args: %w", Name, err)
+    }
+    if err := validateProntoArgs(args); err != nil {
+        return nil, err
+    }
+    return args, nil
+}
+
+func validateProntoArgs(args *ProntoArgs) error {
+    s := args.ScoringStrategy
+    switch s.Type {
+    case LeastLoaded, MostLoaded:
+    case Balanced:
+        if s.TargetUtilization < 0 || s.TargetUtilization > 1 {
+            return fmt.Errorf("scoringStrategy.targetUtilization must be in [0, 1], got %v", s.TargetUtilization)
+        }
+    case Weighted:
+        if s.Weights == nil {
+            return fmt.Errorf("scoringStrategy.weights must be set for the %s strategy", Weighted)
+        }
+    case LowerConfidenceBound, ThompsonSampling:
+        if s.Confidence < 0 {
+            return fmt.Errorf("scoringStrategy.confidence must not be negative, got %v", s.Confidence)
+        }
+        if s.DriftVariance < 0 {
+            return fmt.Errorf("scoringStrategy.driftVariance must not be negative, got %v", s.DriftVariance)
+        }
+    default:
+        return fmt.Errorf("unknown scoringStrategy.type %q", s.Type)
+    }
+
+    if args.MaxSignalAge.Duration < 0 {
+        return fmt.Errorf("maxSignalAge must not be negative, got %v", args.MaxSignalAge.Duration)
+    }
+
+    if args.LowConfidenceMargin < 0 {
+        return fmt.Errorf("lowConfidenceMargin must not be negative, got %v", args.LowConfidenceMargin)
+    }
+
+    if args.Sampling.K < 0 {
+        return fmt.Errorf("sampling.k must not be negative, got %v", args.Sampling.K)
+    }
+
+    if args.SignalSmoothing <= 0 || args.SignalSmoothing > 1 {
+        return fmt.Errorf("signalSmoothing must be in (0, 1], got %v", args.SignalSmoothing)
+    }
+
+    if args.Debug.Address != "" && args.Debug.TokenFile == "" {
+        return fmt.Errorf("debug.tokenFile must be set when debug.address is set")
+    }
+    if (args.Debug.CertFile == "") != (args.Debug.KeyFile == "") {
+        return fmt.Errorf("debug.certFile and debug.keyFile must be set together")
+    }
+    if args.Debug.Address != "" && args.Debug.CertFile == "" && !isLoopback(args.Debug.Address) {
+        return fmt.Errorf("debug.certFile and debug.keyFile must be set unless debug.address is a loopback address, got %q", args.Debug.Address)
+    }
+
+    if args.Audit.MaxSizeMB <= 0 {
+        return fmt.Errorf("audit.maxSizeMB must be positive, got %v", args.Audit.MaxSizeMB)
+    }
+
+    if p := args.Publish; p.Interval.Duration > 0 {
+        if p.MinChange < 0 {
+            return fmt.Errorf("publish.minChange must not be negative, got %v", p.MinChange)
+        }
+        if p.QPS <= 0 || p.Burst <= 0 {
+            return fmt.Errorf("publish.qps and publish.burst must be positive")
+        }
+    }
+
+    seen := map[string]bool{}
+    for _, src := range args.Sources {
+        if _, ok := signalSources[src.Name]; !ok {
+            return fmt.Errorf("unknown signal source %q", src.Name)
+        }
+        if seen[src.Name] {
+            return fmt.Errorf("signal source %q is listed twice", src.Name)
+        }
+        seen[src.Name] = true
+        if src.MaxAge.Duration < 0 {
+            return fmt.Errorf("maxAge of signal source %q must not be negative", src.Name)
+        }
+        if src.Name == remoteWriteSourceName {
+            if err := validateRemoteWriteSource(args.RemoteWriteSource); err != nil {
+                return err
+            }
+        }
+        if src.Name == metricsSourceName {
+            if m := args.MetricsSource; m.Interval.Duration <= 0 || m.PodCPU.Sign() <= 0 || m.PodMemory.Sign() <= 0 {
+                return fmt.Errorf("metricsSource.interval, metricsSource.podCPU and metricsSource.podMemory must be positive")
+            }
+        }
+        if src.Name == replaySourceName {
+            if r := args.ReplaySource; r.Path == "" || r.Speed < 0 {
+                return fmt.Errorf("replaySource.path must be set and replaySource.speed must not be negative")
+            }
+        }
+        if src.Name == orcaSourceName {
+            if err := validateOrcaSource(args.OrcaSource); err != nil {
+                return err
+            }
+        }
+    }
+
+    if a := args.Aggregation; a.Rank <= 0 || a.MaxAge.Duration <= 0 {
+        return fmt.Errorf("aggregation.rank and aggregation.maxAge must be positive")
+    }
+
+    if err := validateScrapeSource(args.ScrapeSource); err != nil {
+        return err
+    }
+
+    n := args.ScoreNormalizer
+    switch n.Type {
+    case MinMaxNormalizer, RankNormalizer, LogNormalizer, AbsoluteNormalizer:
+    case ZScoreNormalizer:
+        if n.ZScoreClip <= 0 {
+            return fmt.Errorf("scoreNormalizer.zScoreClip must be positive, got %v", n.ZScoreClip)
+        }
+    default:
+        return fmt.Errorf("unknown scoreNormalizer.type %q", n.Type)
+    }
+    return nil
+}
+
+func validateScrapeSource(s ScrapeSource) error {
+    if s.Protocol != ScrapeHTTP && s.Protocol != ScrapeGRPC {
+        return fmt.Errorf("unknown scrapeSource.protocol %q", s.Protocol)
+    }
+    if s.PodSelector != nil {
+        if _, err := metav1.LabelSelectorAsSelector(s.PodSelector); err != nil {
+            return fmt.Errorf("scrapeSource.podSelector: %w", err)
+        }
+    }
+    if s.Port <= 0 || s.Port > 65535 {
+        return fmt.Errorf("scrapeSource.port must be in [1, 65535], got %v", s.Port)
+    }
+    if s.Interval.Duration <= 0 || s.Timeout.Duration <= 0 {
+        return fmt.Errorf("scrapeSource.interval and scrapeSource.timeout must be positive")
+    }
+    if s.Jitter < 0 || s.Jitter > 1 {
+        return fmt.Errorf("scrapeSource.jitter must be in [0, 1], got %v", s.Jitter)
+    }
+    if s.Concurrency <= 0 {
+        return fmt.Errorf("scrapeSource.concurrency must be positive, got %v", s.Concurrency)
+    }
+    if s.MaxBackoff.Duration < s.Interval.Duration {
+        return fmt.Errorf("scrapeSource.maxBackoff must not be less than scrapeSource.interval")
+    }
+    return nil
+}
+
+func validateRemoteWriteSource(s RemoteWriteSource) error {
+    if s.Address == "" || s.NodeLabel == "" || !strings.HasPrefix(s.Path, "/") {
+        return fmt.Errorf("remoteWriteSource.address, remoteWriteSource.path and remoteWriteSource.nodeLabel must be set")
+    }
+    if s.Signal == "" || s.Capacity == "" {
+        return fmt.Errorf("remoteWriteSource.signal and remoteWriteSource.capacity must be set")
+    }
+    for field, expr := range map[string]string{"signal": s.Signal, "capacity": s.Capacity, "overprovision": s.Overprovision} {
+        if expr == "" {
+            continue
+        }
+        if _, err := parseSeriesExpr(expr); err != nil {
+            return fmt.Errorf("remoteWriteSource.%s: %w", field, err)
+        }
+    }
+    if s.SeriesTTL.Duration <= 0 {
+        return fmt.Errorf("remoteWriteSource.seriesTTL must be positive, got %v", s.SeriesTTL.Duration)
+    }
+    return nil
+}
+
+func validateOrcaSource(s OrcaSource) error {
+    if s.PodSelector != nil {
+        if _, err := metav1.LabelSelectorAsSelector(s.PodSelector); err != nil {
+            return fmt.Errorf("orcaSource.podSelector: %w", err)
+        }
+    }
+    if s.Port <= 0 || s.Port > 65535 {
+        return fmt.Errorf("orcaSource.port must be in [1, 65535], got %v", s.Port)
+    }
+    if s.ReportInterval.Duration <= 0 || s.ResyncPeriod.Duration <= 0 {
+        return fmt.Errorf("orcaSource.reportInterval and orcaSource.resyncPeriod must be positive")
+    }
+    if s.MaxBackoff.Duration < s.ReportInterval.Duration {
+        return fmt.Errorf("orcaSource.maxBackoff must not be less than orcaSource.reportInterval")
+    }
+    for field, m := range map[string]OrcaMetric{"signal": s.Signal, "capacity": s.Capacity, "overprovision": s.Overprovision} {
+        if !validOrcaKey(m.Key) {
+            return fmt.Errorf("unknown orcaSource.%s.key %q", field, m.Key)
+        }
+    }
+    return nil
+}
+
+// isLoopback reports whether the host of address only accepts connections
+// from the local machine. An empty host listens on every interface.
+func isLoopback(address string) bool {
+    host, _, err := net.SplitHostPort(address)
+    if err != nil {
+        return false
+    }
+    if host == "localhost" {
+        return true
+    }
+    ip := net.ParseIP(host)
+    return ip != nil && ip.IsLoopback()
+}
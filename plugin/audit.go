@@ -0,0 +1,246 @@
+package plugin
+
+import (
+    "encoding/json"
+    "io"
+    "sort"
+    "sync"
+    "time"
+
+    "gopkg.in/natefinch/lumberjack.v2"
+    v1 "k8s.io/api/core/v1"
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// auditLog writes one JSON line per scheduling cycle, rotating the file once
+// it reaches the configured size.
+type auditLog struct {
+    mu  sync.Mutex
+    w   io.WriteCloser
+    enc *json.Encoder
+}
+
+func newAuditLog(cfg Audit) *auditLog {
+    w := &lumberjack.Logger{
+        Filename:   cfg.Path,
+        MaxSize:    cfg.MaxSizeMB,
+        MaxBackups: cfg.MaxBackups,
+        MaxAge:     cfg.MaxAgeDays,
+        Compress:   cfg.Compress,
+    }
+    return &auditLog{w: w, enc: json.NewEncoder(w)}
+}
+
+func (al *auditLog) write(record *AuditRecord) error {
+    al.mu.Lock()
+    defer al.mu.Unlock()
+    return al.enc.Encode(record)
+}
+
+func (al *auditLog) Close() error {
+    al.mu.Lock()
+    defer al.mu.Unlock()
+    return al.w.Close()
+}
+
+// AuditRecord is one line of the audit log: everything Pronto saw and decided
+// in a single scheduling cycle.
+type AuditRecord struct {
+    Time       time.Time         `json:"time"`
+    Pod        string            `json:"pod"`
+    PodUID     string            `json:"podUID"`
+    Cost       float64           `json:"cost"`
+    Candidates []*AuditCandidate `json:"candidates"`
+    ChosenNode string            `json:"chosenNode,omitempty"`
+    Result     string            `json:"result"`
+}
+
+// AuditCandidate is a node considered for the pod.
+type AuditCandidate struct {
+    Node            string     `json:"node"`
+    HostInfo        *AuditHost `json:"hostInfo,omitempty"`
+    Verdict         string     `json:"verdict"`
+    Reason          string     `json:"reason,omitempty"`
+    RawScore        *int64     `json:"rawScore,omitempty"`
+    NormalizedScore *int64     `json:"normalizedScore,omitempty"`
+}
+
+// AuditHost is the HostInfo snapshot a candidate was judged on.
+type AuditHost struct {
+    Signal        float64   `json:"signal"`
+    Capacity      float64   `json:"capacity"`
+    Overprovision float64   `json:"overprovision"`
+    Reserved      int       `json:"reserved"`
+    OverReserved  int       `json:"overReserved"`
+    CapacityMean  float64   `json:"capacityMean"`
+    CapacityVar   float64   `json:"capacityVar"`
+    Samples       int       `json:"samples"`
+    LastUpdate    time.Time `json:"lastUpdate"`
+    Source        string    `json:"source"`
+    LowConfidence bool      `json:"lowConfidence,omitempty"`
+}
+
+func newAuditHost(hi *HostInfo) *AuditHost {
+    return &AuditHost{
+        Signal:        hi.Signal,
+        Capacity:      hi.Capacity,
+        Overprovision: hi.Overprovision,
+        Reserved:      hi.Reserved,
+        OverReserved:  hi.OverReserved,
+        CapacityMean:  hi.CapacityMean,
+        CapacityVar:   hi.CapacityVar,
+        Samples:       hi.Samples,
+        LastUpdate:    hi.LastUpdate,
+        Source:        hi.Source,
+        LowConfidence: hi.LowConfidence,
+    }
+}
+
+// Values of AuditRecord.Result.
+const (
+    auditScheduled     = "scheduled"
+    auditUnschedulable = "unschedulable"
+    auditFailed        = "failed"
+)
+
+const auditStateKey framework.StateKey = Name + "/audit"
+
+// cycleAudit collects the audit record of a cycle. Filter and Score run in
+// parallel across nodes, so it is guarded by a mutex.
+type cycleAudit struct {
+    mu         sync.Mutex
+    record     *AuditRecord
+    candidates map[string]*AuditCandidate
+}
+
+// Clone deep-copies the record so that preemption dry runs, which run
+// Filter on a cloned state, do not leak verdicts into the cycle's record.
+func (ca *cycleAudit) Clone() framework.StateData {
+    ca.mu.Lock()
+    defer ca.mu.Unlock()
+
+    record := *ca.record
+    record.Candidates = nil
+    clone := &cycleAudit{
+        record:     &record,
+        candidates: make(map[string]*AuditCandidate, len(ca.candidates)),
+    }
+    for name, c := range ca.candidates {
+        clone.candidates[name] = c.clone()
+    }
+    return clone
+}
+
+func (c *AuditCandidate) clone() *AuditCandidate {
+    cc := *c
+    if c.HostInfo != nil {
+        hi := *c.HostInfo
+        cc.HostInfo = &hi
+    }
+    if c.RawScore != nil {
+        score := *c.RawScore
+        cc.RawScore = &score
+    }
+    if c.NormalizedScore != nil {
+        score := *c.NormalizedScore
+        cc.NormalizedScore = &score
+    }
+    return &cc
+}
+
+func (ca *cycleAudit) candidate(nodeName string) *AuditCandidate {
+    c, ok := ca.candidates[nodeName]
+    if !ok {
+        c = &AuditCandidate{Node: nodeName}
+        ca.candidates[nodeName] = c
+    }
+    return c
+}
+
+func (ca *cycleAudit) filtered(nodeName string, hi *HostInfo, verdict, reason string) {
+    ca.mu.Lock()
+    defer ca.mu.Unlock()
+
+    c := ca.candidate(nodeName)
+    if hi != nil {
+        c.HostInfo = newAuditHost(hi)
+    }
+    c.Verdict = verdict
+    c.Reason = reason
+}
+
+func (ca *cycleAudit) scored(nodeName string, score int64) {
+    ca.mu.Lock()
+    defer ca.mu.Unlock()
+
+    ca.candidate(nodeName).RawScore = &score
+}
+
+func (ca *cycleAudit) normalized(scores framework.NodeScoreList) {
+    ca.mu.Lock()
+    defer ca.mu.Unlock()
+
+    for _, nodeScore := range scores {
+        score := nodeScore.Score
+        ca.candidate(nodeScore.Name).NormalizedScore = &score
+    }
+}
+
+// finish completes the record. It returns nil if the record was already
+// finished, so each cycle is written once.
+func (ca *cycleAudit) finish(chosen, result string) *AuditRecord {
+    ca.mu.Lock()
+    defer ca.mu.Unlock()
+
+    if ca.record.Result != "" {
+        return nil
+    }
+    ca.record.ChosenNode = chosen
+    ca.record.Result = result
+    ca.record.Candidates = make([]*AuditCandidate, 0, len(ca.candidates))
+    for _, c := range ca.candidates {
+        ca.record.Candidates = append(ca.record.Candidates, c)
+    }
+    sort.Slice(ca.record.Candidates, func(i, j int) bool {
+        return ca.record.Candidates[i].Node < ca.record.Candidates[j].Node
+    })
+    return ca.record
+}
+
+// startAudit begins the audit record of a cycle if the audit log is enabled.
+func (pl *ProntoPlugin) startAudit(state *framework.CycleState, pod *v1.Pod) {
+    if pl.audit == nil {
+        return
+    }
+    state.Write(auditStateKey, &cycleAudit{
+        record: &AuditRecord{
+            Time:   pl.clock.Now(),
+            Pod:    pod.Namespace + "/" + pod.Name,
+            PodUID: string(pod.UID),
+            Cost:   podCost,
+        },
+        candidates: make(map[string]*AuditCandidate),
+    })
+}
+
+// cycleAuditFrom returns the cycle's audit record, or nil if auditing is off.
+func cycleAuditFrom(state *framework.CycleState) *cycleAudit {
+    ca, err := state.Read(auditStateKey)
+    if err != nil {
+        return nil
+    }
+    return ca.(*cycleAudit)
+}
+
+// finishAudit writes the cycle's audit record.
+func (pl *ProntoPlugin) finishAudit(state *framework.CycleState, chosen, result string) {
+    ca := cycleAuditFrom(state)
+    if ca == nil {
+        return
+    }
+    if record := ca.finish(chosen, result); record != nil {
+        if err := pl.audit.write(record); err != nil {
+            pl.logger.Error(err, "Writing audit record", "pod", record.Pod)
+        }
+    }
+}
@@ -0,0 +1,158 @@
+package plugin
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/go-logr/logr"
+    "go.opentelemetry.io/otel/trace/noop"
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/types"
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+// readAudit decodes every record of the audit log at path.
+func readAudit(t *testing.T, path string) []*AuditRecord {
+    t.Helper()
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    var records []*AuditRecord
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        var record AuditRecord
+        if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+            t.Fatalf("decoding audit line %q: %v", scanner.Text(), err)
+        }
+        records = append(records, &record)
+    }
+    if err := scanner.Err(); err != nil {
+        t.Fatal(err)
+    }
+    return records
+}
+
+func TestAuditLog(t *testing.T) {
+    ctx := context.Background()
+    now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+    path := filepath.Join(t.TempDir(), "audit.log")
+    pl := &ProntoPlugin{args: &ProntoArgs{}, logger: logr.Discard(),
+        audit: newAuditLog(Audit{Path: path, MaxSizeMB: 1})}
+    pl.clock = clocktesting.NewFakePassiveClock(now)
+    pl.tracer = noop.NewTracerProvider().Tracer("")
+
+    pod := func(name string) *v1.Pod {
+        return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID("uid-" + name)}}
+    }
+    cycle := func(p *v1.Pod) *framework.CycleState {
+        state := framework.NewCycleState()
+        pl.PreFilter(ctx, state, p)
+        return state
+    }
+
+    // A bound pod is finished by PostBind, and a later Unreserve of the same
+    // cycle does not write it again.
+    bound := cycle(pod("bound"))
+    audit := cycleAuditFrom(bound)
+    audit.filtered("b", &HostInfo{Capacity: 0.5, Samples: 1, LastUpdate: now, Source: metricsSourceName},
+        rejectNoCapacity, "no room")
+    audit.filtered("a", &HostInfo{Signal: 0.25, Capacity: 4, CapacityMean: 3.5, CapacityVar: 0.25,
+        Reserved: 1, Samples: 3, LastUpdate: now.Add(-time.Second), Source: metricsSourceName},
+        verdictFits, "")
+    audit.scored("a", 300)
+    audit.normalized(framework.NodeScoreList{{Name: "a", Score: 100}})
+    pl.PostBind(ctx, bound, pod("bound"), "a")
+    pl.Unreserve(ctx, bound, pod("bound"), "a")
+
+    // A pod that fails after Reserve is finished by Unreserve.
+    failed := cycle(pod("failed"))
+    cycleAuditFrom(failed).filtered("a", nil, verdictFits, "")
+    pl.Unreserve(ctx, failed, pod("failed"), "a")
+
+    // A pod no node could take is finished by PostFilter.
+    unschedulable := cycle(pod("unschedulable"))
+    cycleAuditFrom(unschedulable).filtered("a", nil, rejectNoData, "no signal")
+    pl.PostFilter(ctx, unschedulable, pod("unschedulable"), nil)
+
+    if err := pl.audit.Close(); err != nil {
+        t.Fatal(err)
+    }
+
+    raw, normalized := int64(300), int64(100)
+    want := []*AuditRecord{
+        {
+            Time: now, Pod: "default/bound", PodUID: "uid-bound", Cost: podCost,
+            Candidates: []*AuditCandidate{
+                {
+                    Node: "a",
+                    HostInfo: &AuditHost{Signal: 0.25, Capacity: 4, CapacityMean: 3.5, CapacityVar: 0.25,
+                        Reserved: 1, Samples: 3, LastUpdate: now.Add(-time.Second), Source: metricsSourceName},
+                    Verdict:         verdictFits,
+                    RawScore:        &raw,
+                    NormalizedScore: &normalized,
+                },
+                {
+                    Node:     "b",
+                    HostInfo: &AuditHost{Capacity: 0.5, Samples: 1, LastUpdate: now, Source: metricsSourceName},
+                    Verdict:  rejectNoCapacity,
+                    Reason:   "no room",
+                },
+            },
+            ChosenNode: "a",
+            Result:     auditScheduled,
+        },
+        {
+            Time: now, Pod: "default/failed", PodUID: "uid-failed", Cost: podCost,
+            Candidates: []*AuditCandidate{{Node: "a", Verdict: verdictFits}},
+            ChosenNode: "a",
+            Result:     auditFailed,
+        },
+        {
+            Time: now, Pod: "default/unschedulable", PodUID: "uid-unschedulable", Cost: podCost,
+            Candidates: []*AuditCandidate{{Node: "a", Verdict: rejectNoData, Reason: "no signal"}},
+            Result:     auditUnschedulable,
+        },
+    }
+    got := readAudit(t, path)
+    if len(got) != len(want) {
+        t.Fatalf("audit log has %d records, want %d", len(got), len(want))
+    }
+    for i := range want {
+        if !reflect.DeepEqual(got[i], want[i]) {
+            g, _ := json.Marshal(got[i])
+            w, _ := json.Marshal(want[i])
+            t.Errorf("record %d = %s, want %s", i, g, w)
+        }
+    }
+}
+
+func TestAuditClone(t *testing.T) {
+    pl := &ProntoPlugin{audit: &auditLog{}}
+    pl.clock = clocktesting.NewFakePassiveClock(time.Unix(0, 0))
+    state := framework.NewCycleState()
+    pl.startAudit(state, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p"}})
+    cycleAuditFrom(state).scored("a", 1)
+
+    // A preemption dry run filters on a clone of the cycle state.
+    clone := state.Clone()
+    cycleAuditFrom(clone).filtered("a", nil, rejectNoCapacity, "")
+    cycleAuditFrom(clone).scored("a", 2)
+
+    record := cycleAuditFrom(state).finish("a", auditScheduled)
+    if c := record.Candidates[0]; c.Verdict != "" || *c.RawScore != 1 {
+        t.Errorf("clone leaked into the cycle's record: verdict %q, score %d", c.Verdict, *c.RawScore)
+    }
+    if cycleAuditFrom(state).finish("a", auditFailed) != nil {
+        t.Error("record finished twice")
+    }
+}
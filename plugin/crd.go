@@ -0,0 +1,169 @@
+package plugin
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/go-logr/logr"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s.io/client-go/dynamic"
+    "k8s.io/client-go/dynamic/dynamicinformer"
+    "k8s.io/client-go/rest"
+    "k8s.io/client-go/tools/cache"
+)
+
+// ProntoSignalGVR identifies the ProntoSignal custom resource. Node agents
+// that cannot reach the gRPC server write their signal to the status of the
+// ProntoSignal named after their node.
+var ProntoSignalGVR = schema.GroupVersionResource{
+    Group:    "pronto.io",
+    Version:  "v1alpha1",
+    Resource: "prontosignals",
+}
+
+// crdSignal is the status of a ProntoSignal.
+type crdSignal struct {
+    signal        float64
+    capacity      float64
+    overprovision float64
+    timestamp     time.Time
+}
+
+const crdSourceName = "crd"
+
+// crdSource feeds ProntoSignal objects into prontoState.
+type crdSource struct {
+    state  *prontoState
+    config *rest.Config
+    resync time.Duration
+    logger logr.Logger
+    // seen holds the timestamp of the last status ingested per node. The
+    // informer calls its handlers from a single goroutine.
+    seen map[string]time.Time
+}
+
+func newCRDSource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    return &crdSource{
+        state:  &pl.prontoState,
+        config: pl.handle.KubeConfig(),
+        resync: pl.args.CRDSource.ResyncPeriod.Duration,
+        logger: logger,
+        seen:   make(map[string]time.Time),
+    }, nil
+}
+
+func (src *crdSource) Name() string { return crdSourceName }
+
+func (src *crdSource) Start(ctx context.Context) error {
+    client, err := dynamic.NewForConfig(src.config)
+    if err != nil {
+        return fmt.Errorf("creating dynamic client: %w", err)
+    }
+
+    factory := dynamicinformer.NewDynamicSharedInformerFactory(client, src.resync)
+    informer := factory.ForResource(ProntoSignalGVR).Informer()
+    informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+        AddFunc: src.onAdd,
+        UpdateFunc: func(oldObj, newObj interface{}) {
+            src.onAdd(newObj)
+        },
+        DeleteFunc: src.onDelete,
+    })
+    factory.Start(ctx.Done())
+    return nil
+}
+
+func (src *crdSource) onAdd(obj interface{}) {
+    u, ok := obj.(*unstructured.Unstructured)
+    if !ok {
+        return
+    }
+    s, err := parseCRDSignal(u)
+    if err != nil {
+        signalSamples.WithLabelValues(sampleInvalidValue).Inc()
+        src.logger.Error(err, "Parsing ProntoSignal", "name", u.GetName())
+        return
+    }
+    // Without a time the sample may be arbitrarily old, e.g. left over from
+    // an agent that has since stopped.
+    if s.timestamp.IsZero() {
+        signalSamples.WithLabelValues(sampleStale).Inc()
+        src.logger.V(4).Info("Ignoring ProntoSignal without a timestamp", "name", u.GetName())
+        return
+    }
+    // Resyncs redeliver objects whose status has not changed.
+    if seen, ok := src.seen[u.GetName()]; ok && !s.timestamp.After(seen) {
+        return
+    }
+    src.seen[u.GetName()] = s.timestamp
+
+    signalSamples.WithLabelValues(sampleAccepted).Inc()
+    src.state.ingestAt(crdSourceName, u.GetName(), s.timestamp, WithCapacity(s.capacity),
+        WithSignal(s.signal),
+        WithOverprovision(s.overprovision))
+}
+
+func (src *crdSource) onDelete(obj interface{}) {
+    if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+        obj = tombstone.Obj
+    }
+    if u, ok := obj.(*unstructured.Unstructured); ok {
+        delete(src.seen, u.GetName())
+    }
+}
+
+func parseCRDSignal(u *unstructured.Unstructured) (*crdSignal, error) {
+    s := &crdSignal{}
+    for field, into := range map[string]*float64{
+        "signal":        &s.signal,
+        "capacity":      &s.capacity,
+        "overprovision": &s.overprovision,
+    } {
+        v, found, err := unstructured.NestedFieldNoCopy(u.Object, "status", field)
+        if err != nil {
+            return nil, err
+        }
+        if !found {
+            return nil, fmt.Errorf("status.%s is missing", field)
+        }
+        // Whole numbers decode as int64.
+        switch n := v.(type) {
+        case float64:
+            *into = n
+        case int64:
+            *into = float64(n)
+        default:
+            return nil, fmt.Errorf("status.%s is a %T, not a number", field, v)
+        }
+    }
+
+    ts, found, err := unstructured.NestedString(u.Object, "status", "timestamp")
+    if err != nil {
+        return nil, err
+    }
+    if found {
+        if s.timestamp, err = time.Parse(time.RFC3339, ts); err != nil {
+            return nil, fmt.Errorf("parsing status.timestamp: %w", err)
+        }
+    } else {
+        s.timestamp = statusUpdateTime(u)
+    }
+    return s, nil
+}
+
+// statusUpdateTime returns when the object's status was last written
+// according to its managed fields, or the zero time if none records it.
+func statusUpdateTime(u *unstructured.Unstructured) time.Time {
+    var latest time.Time
+    for _, entry := range u.GetManagedFields() {
+        if entry.Subresource != "status" || entry.Time == nil {
+            continue
+        }
+        if entry.Time.After(latest) {
+            latest = entry.Time.Time
+        }
+    }
+    return latest
+}
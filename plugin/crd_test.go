@@ -0,0 +1,97 @@
+package plugin
+
+import (
+    "testing"
+    "time"
+
+    "github.com/go-logr/logr"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newProntoSignal(name string, status map[string]interface{}, managed ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+    u := &unstructured.Unstructured{Object: map[string]interface{}{
+        "apiVersion": "pronto.io/v1alpha1",
+        "kind":       "ProntoSignal",
+        "status":     status,
+    }}
+    u.SetName(name)
+    u.SetManagedFields(managed)
+    return u
+}
+
+func TestCRDSourceSampleTime(t *testing.T) {
+    written := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+    status := func(timestamp string) map[string]interface{} {
+        s := map[string]interface{}{"signal": 0.5, "capacity": int64(3), "overprovision": 0.0}
+        if timestamp != "" {
+            s["timestamp"] = timestamp
+        }
+        return s
+    }
+    managed := func(subresource string) metav1.ManagedFieldsEntry {
+        return metav1.ManagedFieldsEntry{
+            Manager:     "pronto-agent",
+            Subresource: subresource,
+            Time:        &metav1.Time{Time: written},
+        }
+    }
+
+    tests := []struct {
+        name string
+        obj  *unstructured.Unstructured
+        // want is the node's LastUpdate, zero if the sample is dropped.
+        want time.Time
+    }{
+        {
+            name: "status timestamp",
+            obj:  newProntoSignal("n", status(written.Format(time.RFC3339))),
+            want: written,
+        },
+        {
+            name: "status subresource managed fields",
+            obj:  newProntoSignal("n", status(""), managed(""), managed("status")),
+            want: written,
+        },
+        {
+            name: "no status write recorded",
+            obj:  newProntoSignal("n", status(""), managed("")),
+        },
+        {
+            name: "no time at all",
+            obj:  newProntoSignal("n", status("")),
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            src := &crdSource{
+                state:  newTestState(),
+                logger: logr.Discard(),
+                seen:   make(map[string]time.Time),
+            }
+            // A resync delivers the object again.
+            src.onAdd(tt.obj)
+            src.onAdd(tt.obj)
+
+            hi := src.state.GetHost("n")
+            if tt.want.IsZero() {
+                if hi != nil {
+                    t.Errorf("ingested %+v, want the sample dropped", hi)
+                }
+                return
+            }
+            if hi == nil {
+                t.Fatal("sample was not ingested")
+            }
+            if !hi.LastUpdate.Equal(tt.want) {
+                t.Errorf("LastUpdate = %v, want %v", hi.LastUpdate, tt.want)
+            }
+            if hi.Samples != 1 {
+                t.Errorf("Samples = %d, want 1 after a resync", hi.Samples)
+            }
+            if hi.Capacity != 3 {
+                t.Errorf("Capacity = %v, want 3", hi.Capacity)
+            }
+        })
+    }
+}
@@ -0,0 +1,200 @@
+package plugin
+
+import (
+    "context"
+    "crypto/subtle"
+    "crypto/tls"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math"
+    "net"
+    "net/http"
+    "os"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/go-logr/logr"
+)
+
+// startDebugServer serves a JSON dump of prontoState on the address in the
+// plugin args. Every request must carry the bearer token from the token file,
+// so the server uses TLS unless it only listens on loopback. The token,
+// certificate and address are checked before it returns, so that a
+// misconfigured server fails the plugin rather than only being logged.
+func (pl *ProntoPlugin) startDebugServer(ctx context.Context, logger logr.Logger) error {
+    cfg := pl.args.Debug
+    data, err := os.ReadFile(cfg.TokenFile)
+    if err != nil {
+        return fmt.Errorf("reading debug token file: %w", err)
+    }
+    token := strings.TrimSpace(string(data))
+    if token == "" {
+        return fmt.Errorf("debug token file %s is empty", cfg.TokenFile)
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle("/debug/pronto/state", withBearerToken(token, http.HandlerFunc(pl.serveState)))
+    srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+    if cfg.CertFile != "" {
+        cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+        if err != nil {
+            return fmt.Errorf("loading debug certificate: %w", err)
+        }
+        srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+    }
+
+    ln, err := net.Listen("tcp", cfg.Address)
+    if err != nil {
+        return fmt.Errorf("listening on debug address: %w", err)
+    }
+    if srv.TLSConfig != nil {
+        ln = tls.NewListener(ln, srv.TLSConfig)
+    }
+
+    go func() {
+        if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            logger.Error(err, "Debug server failed")
+        }
+    }()
+
+    go func() {
+        <-ctx.Done()
+        srv.Shutdown(context.Background())
+    }()
+
+    if logger.V(4).Enabled() {
+        logger.Info("Started debug server", "address", ln.Addr().String())
+    }
+    return nil
+}
+
+func withBearerToken(token string, next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+        if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+            http.Error(w, "unauthorized", http.StatusUnauthorized)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+type debugHost struct {
+    Node              string    `json:"node"`
+    Signal            float64   `json:"signal"`
+    Capacity          float64   `json:"capacity"`
+    Overprovision     float64   `json:"overprovision"`
+    Reserved          int       `json:"reserved"`
+    OverReserved      int       `json:"overReserved"`
+    CapacityMean      float64   `json:"capacityMean"`
+    CapacityStdDev    float64   `json:"capacityStdDev"`
+    Samples           int       `json:"samples"`
+    PlacedSinceSignal int       `json:"placedSinceSignal"`
+    LastUpdate        time.Time `json:"lastUpdate"`
+    StalenessSeconds  float64   `json:"stalenessSeconds"`
+    Source            string    `json:"source"`
+    LowConfidence     bool      `json:"lowConfidence"`
+}
+
+type debugReservation struct {
+    Pod           string    `json:"pod"`
+    Node          string    `json:"node"`
+    ReservedAt    time.Time `json:"reservedAt"`
+    Overprovision bool      `json:"overprovision"`
+}
+
+type debugAgent struct {
+    Node        string    `json:"node"`
+    Peer        string    `json:"peer"`
+    ConnectedAt time.Time `json:"connectedAt"`
+    Samples     int       `json:"samples"`
+}
+
+type debugState struct {
+    Hosts        []debugHost        `json:"hosts"`
+    Reservations []debugReservation `json:"reservations"`
+    Agents       []debugAgent       `json:"agents"`
+}
+
+// serveState writes the plugin's state as JSON. The node and pod query
+// parameters restrict the output to one node or one pod's reservation.
+func (pl *ProntoPlugin) serveState(w http.ResponseWriter, r *http.Request) {
+    node := r.URL.Query().Get("node")
+    pod := r.URL.Query().Get("pod")
+
+    w.Header().Set("Content-Type", "application/json")
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    if err := enc.Encode(pl.debugState(node, pod, pl.clock.Now())); err != nil {
+        pl.logger.Error(err, "Writing debug state")
+    }
+}
+
+func (ps *prontoState) debugState(node, pod string, now time.Time) *debugState {
+    ps.mu.Lock()
+    defer ps.mu.Unlock()
+
+    ds := &debugState{
+        Hosts:        []debugHost{},
+        Reservations: []debugReservation{},
+        Agents:       []debugAgent{},
+    }
+    for name, r := range ps.PodReserved {
+        if (node == "" || r.Node == node) && (pod == "" || name == pod) {
+            ds.Reservations = append(ds.Reservations, debugReservation{Pod: name, Node: r.Node, ReservedAt: r.ReservedAt})
+        }
+    }
+    for name, r := range ps.PodOverReserved {
+        if (node == "" || r.Node == node) && (pod == "" || name == pod) {
+            ds.Reservations = append(ds.Reservations, debugReservation{Pod: name, Node: r.Node, ReservedAt: r.ReservedAt, Overprovision: true})
+        }
+    }
+
+    // Filtering by pod narrows the hosts and agents to the pod's node.
+    nodes := map[string]bool{}
+    if pod != "" {
+        for _, r := range ds.Reservations {
+            nodes[r.Node] = true
+        }
+    }
+    include := func(name string) bool {
+        return (node == "" || name == node) && (pod == "" || nodes[name])
+    }
+
+    for name, hi := range ps.HostReservations {
+        if !include(name) {
+            continue
+        }
+        h := debugHost{
+            Node:              name,
+            Signal:            hi.Signal,
+            Capacity:          hi.Capacity,
+            Overprovision:     hi.Overprovision,
+            Reserved:          hi.Reserved,
+            OverReserved:      hi.OverReserved,
+            CapacityMean:      hi.CapacityMean,
+            CapacityStdDev:    math.Sqrt(hi.CapacityVar),
+            Samples:           hi.Samples,
+            PlacedSinceSignal: hi.PlacedSinceSignal,
+            LastUpdate:        hi.LastUpdate,
+            Source:            hi.Source,
+            LowConfidence:     hi.LowConfidence,
+        }
+        if !hi.LastUpdate.IsZero() {
+            h.StalenessSeconds = now.Sub(hi.LastUpdate).Seconds()
+        }
+        ds.Hosts = append(ds.Hosts, h)
+    }
+    for name, agent := range ps.Agents {
+        if include(name) {
+            ds.Agents = append(ds.Agents, debugAgent{Node: name, Peer: agent.Peer, ConnectedAt: agent.ConnectedAt, Samples: agent.Samples})
+        }
+    }
+
+    sort.Slice(ds.Hosts, func(i, j int) bool { return ds.Hosts[i].Node < ds.Hosts[j].Node })
+    sort.Slice(ds.Reservations, func(i, j int) bool { return ds.Reservations[i].Pod < ds.Reservations[j].Pod })
+    sort.Slice(ds.Agents, func(i, j int) bool { return ds.Agents[i].Node < ds.Agents[j].Node })
+    return ds
+}
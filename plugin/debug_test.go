@@ -0,0 +1,139 @@
+package plugin
+
+import (
+    "context"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/go-logr/logr"
+)
+
+func TestWithBearerToken(t *testing.T) {
+    handler := withBearerToken("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+    }))
+    for _, tc := range []struct {
+        name          string
+        authorization string
+        want          int
+    }{
+        {name: "missing", want: http.StatusUnauthorized},
+        {name: "wrong", authorization: "Bearer other", want: http.StatusUnauthorized},
+        {name: "empty", authorization: "Bearer ", want: http.StatusUnauthorized},
+        {name: "not bearer", authorization: "Basic secret", want: http.StatusUnauthorized},
+        {name: "prefix", authorization: "Bearer secre", want: http.StatusUnauthorized},
+        {name: "valid", authorization: "Bearer secret", want: http.StatusOK},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            r := httptest.NewRequest(http.MethodGet, "/debug/pronto/state", nil)
+            if tc.authorization != "" {
+                r.Header.Set("Authorization", tc.authorization)
+            }
+            w := httptest.NewRecorder()
+            handler.ServeHTTP(w, r)
+            if w.Code != tc.want {
+                t.Errorf("status = %d, want %d", w.Code, tc.want)
+            }
+        })
+    }
+}
+
+func TestStartDebugServer(t *testing.T) {
+    dir := t.TempDir()
+    writeToken := func(name, token string) string {
+        path := filepath.Join(dir, name)
+        if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+            t.Fatal(err)
+        }
+        return path
+    }
+    busy, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer busy.Close()
+
+    for _, tc := range []struct {
+        name    string
+        debug   Debug
+        wantErr string
+    }{
+        {
+            name:    "missing token file",
+            debug:   Debug{Address: "127.0.0.1:0", TokenFile: filepath.Join(dir, "missing")},
+            wantErr: "reading debug token file",
+        },
+        {
+            name:    "empty token",
+            debug:   Debug{Address: "127.0.0.1:0", TokenFile: writeToken("empty", "")},
+            wantErr: "is empty",
+        },
+        {
+            name:    "whitespace token",
+            debug:   Debug{Address: "127.0.0.1:0", TokenFile: writeToken("blank", " \n\t\n")},
+            wantErr: "is empty",
+        },
+        {
+            name: "missing certificate",
+            debug: Debug{Address: "127.0.0.1:0", TokenFile: writeToken("cert", "secret"),
+                CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")},
+            wantErr: "loading debug certificate",
+        },
+        {
+            name:    "address in use",
+            debug:   Debug{Address: busy.Addr().String(), TokenFile: writeToken("busy", "secret")},
+            wantErr: "listening on debug address",
+        },
+        {
+            name:  "valid",
+            debug: Debug{Address: "127.0.0.1:0", TokenFile: writeToken("valid", "secret\n")},
+        },
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            ctx, cancel := context.WithCancel(context.Background())
+            defer cancel()
+            pl := &ProntoPlugin{args: &ProntoArgs{Debug: tc.debug}}
+            err := pl.startDebugServer(ctx, logr.Discard())
+            if tc.wantErr == "" {
+                if err != nil {
+                    t.Fatalf("startDebugServer() = %v", err)
+                }
+                return
+            }
+            if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+                t.Errorf("startDebugServer() = %v, want an error containing %q", err, tc.wantErr)
+            }
+        })
+    }
+}
+
+func TestDebugArgsRequireTLSOffLoopback(t *testing.T) {
+    for _, tc := range []struct {
+        name    string
+        debug   Debug
+        wantErr bool
+    }{
+        {name: "disabled", debug: Debug{}},
+        {name: "loopback", debug: Debug{Address: "127.0.0.1:9443", TokenFile: "token"}},
+        {name: "localhost", debug: Debug{Address: "localhost:9443", TokenFile: "token"}},
+        {name: "ipv6 loopback", debug: Debug{Address: "[::1]:9443", TokenFile: "token"}},
+        {name: "all interfaces", debug: Debug{Address: ":9443", TokenFile: "token"}, wantErr: true},
+        {name: "pod address", debug: Debug{Address: "10.0.0.1:9443", TokenFile: "token"}, wantErr: true},
+        {name: "pod address with TLS", debug: Debug{Address: "10.0.0.1:9443", TokenFile: "token", CertFile: "tls.crt", KeyFile: "tls.key"}},
+        {name: "no token", debug: Debug{Address: "127.0.0.1:9443"}, wantErr: true},
+        {name: "certificate without key", debug: Debug{Address: "10.0.0.1:9443", TokenFile: "token", CertFile: "tls.crt"}, wantErr: true},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            args := defaultProntoArgs()
+            args.Debug = tc.debug
+            if err := validateProntoArgs(args); (err != nil) != tc.wantErr {
+                t.Errorf("validateProntoArgs() = %v, want error %v", err, tc.wantErr)
+            }
+        })
+    }
+}
@@ -0,0 +1,115 @@
+package plugin
+
+import (
+    "fmt"
+    "math"
+    "strings"
+    "sync"
+
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// filterReasons are the messages Filter rejects nodes with. They do not name
+// the node, so the scheduler can aggregate them into a readable histogram
+// such as "3 insufficient Pronto capacity".
+var filterReasons = map[string]string{
+    rejectNoData:     "node has no Pronto signal",
+    rejectStale:      "node's Pronto signal is stale",
+    rejectNoCapacity: "insufficient Pronto capacity",
+}
+
+const diagnosisStateKey framework.StateKey = Name + "/diagnosis"
+
+// cycleDiagnosis tallies why Filter rejected nodes in a cycle and remembers
+// the rejected node that came closest to fitting.
+type cycleDiagnosis struct {
+    mu               sync.Mutex
+    rejections       map[string]int
+    nearMiss         string
+    nearMissHeadroom float64
+}
+
+func newCycleDiagnosis() *cycleDiagnosis {
+    return &cycleDiagnosis{
+        rejections:       make(map[string]int),
+        nearMissHeadroom: math.Inf(-1),
+    }
+}
+
+// Clone copies the tallies so that preemption dry runs, which run Filter on
+// a cloned state, do not count their rejections against the cycle.
+func (cd *cycleDiagnosis) Clone() framework.StateData {
+    cd.mu.Lock()
+    defer cd.mu.Unlock()
+
+    clone := &cycleDiagnosis{
+        rejections:       make(map[string]int, len(cd.rejections)),
+        nearMiss:         cd.nearMiss,
+        nearMissHeadroom: cd.nearMissHeadroom,
+    }
+    for verdict, n := range cd.rejections {
+        clone.rejections[verdict] = n
+    }
+    return clone
+}
+
+func (cd *cycleDiagnosis) rejected(nodeName string, hi *HostInfo, verdict string) {
+    cd.mu.Lock()
+    defer cd.mu.Unlock()
+
+    cd.rejections[verdict] += 1
+    if verdict != rejectNoCapacity {
+        return
+    }
+    if h := headroom(hi); h > cd.nearMissHeadroom || (h == cd.nearMissHeadroom && nodeName < cd.nearMiss) {
+        cd.nearMiss = nodeName
+        cd.nearMissHeadroom = h
+    }
+}
+
+// summary explains in one sentence why no node could take the pod, e.g.
+// "Pronto rejected 5 nodes: 2 without a signal, 3 without enough capacity;
+// closest was node-a with 0.50 capacity left of 1 needed."
+func (cd *cycleDiagnosis) summary() string {
+    cd.mu.Lock()
+    defer cd.mu.Unlock()
+
+    total := 0
+    var parts []string
+    for _, r := range []struct {
+        verdict string
+        text    string
+    }{
+        {rejectNoData, "without a signal"},
+        {rejectStale, "with a stale signal"},
+        {rejectNoCapacity, "without enough capacity"},
+    } {
+        if n := cd.rejections[r.verdict]; n > 0 {
+            total += n
+            parts = append(parts, fmt.Sprintf("%d %s", n, r.text))
+        }
+    }
+    if total == 0 {
+        return ""
+    }
+
+    nodes := "nodes"
+    if total == 1 {
+        nodes = "node"
+    }
+    msg := fmt.Sprintf("Pronto rejected %d %s: %s", total, nodes, strings.Join(parts, ", "))
+    if cd.nearMiss != "" {
+        msg += fmt.Sprintf("; closest was %s with %.2f capacity left of %d needed", cd.nearMiss, cd.nearMissHeadroom, podCost)
+    }
+    return msg + "."
+}
+
+// cycleDiagnosisFrom returns the cycle's diagnosis, or nil if PreFilter did
+// not run.
+func cycleDiagnosisFrom(state *framework.CycleState) *cycleDiagnosis {
+    cd, err := state.Read(diagnosisStateKey)
+    if err != nil {
+        return nil
+    }
+    return cd.(*cycleDiagnosis)
+}
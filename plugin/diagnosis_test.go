@@ -0,0 +1,19 @@
+package plugin
+
+import "testing"
+
+func TestCycleDiagnosisClone(t *testing.T) {
+    cd := newCycleDiagnosis()
+    cd.rejected("a", &HostInfo{Capacity: 0.5}, rejectNoCapacity)
+
+    clone := cd.Clone().(*cycleDiagnosis)
+    clone.rejected("b", &HostInfo{Capacity: 0.8}, rejectNoCapacity)
+    clone.rejected("c", nil, rejectNoData)
+
+    if got, want := cd.summary(), "Pronto rejected 1 node: 1 without enough capacity; closest was a with 0.50 capacity left of 1 needed."; got != want {
+        t.Errorf("original summary = %q, want %q", got, want)
+    }
+    if got, want := clone.summary(), "Pronto rejected 3 nodes: 1 without a signal, 2 without enough capacity; closest was b with 0.80 capacity left of 1 needed."; got != want {
+        t.Errorf("clone summary = %q, want %q", got, want)
+    }
+}
@@ -0,0 +1,87 @@
+package plugin
+
+import (
+    "fmt"
+    "net"
+    "strconv"
+
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/labels"
+    corelisters "k8s.io/client-go/listers/core/v1"
+    "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// agentTarget is the endpoint of a node's agent.
+type agentTarget struct {
+    node    string
+    address string
+}
+
+// agentDiscovery finds the node agents that Pronto connects to itself: the
+// running pods matching a selector, or every node on its InternalIP.
+type agentDiscovery struct {
+    selector  labels.Selector
+    namespace string
+    port      string
+    pods      corelisters.PodLister
+    nodes     corelisters.NodeLister
+}
+
+func newAgentDiscovery(handle framework.Handle, podSelector *metav1.LabelSelector, namespace string, port int32) (*agentDiscovery, error) {
+    d := &agentDiscovery{
+        namespace: namespace,
+        port:      strconv.Itoa(int(port)),
+    }
+    informers := handle.SharedInformerFactory().Core().V1()
+    if podSelector != nil {
+        selector, err := metav1.LabelSelectorAsSelector(podSelector)
+        if err != nil {
+            return nil, fmt.Errorf("parsing podSelector: %w", err)
+        }
+        d.selector = selector
+        d.pods = informers.Pods().Lister()
+    } else {
+        d.nodes = informers.Nodes().Lister()
+    }
+    return d, nil
+}
+
+// targets lists the agent endpoints. The listers are empty until the
+// scheduler starts its informers.
+func (d *agentDiscovery) targets() ([]agentTarget, error) {
+    var targets []agentTarget
+    if d.pods != nil {
+        pods, err := d.pods.Pods(d.namespace).List(d.selector)
+        if err != nil {
+            return nil, err
+        }
+        for _, pod := range pods {
+            if pod.Spec.NodeName == "" || pod.Status.PodIP == "" || pod.Status.Phase != v1.PodRunning {
+                continue
+            }
+            targets = append(targets, agentTarget{
+                node:    pod.Spec.NodeName,
+                address: net.JoinHostPort(pod.Status.PodIP, d.port),
+            })
+        }
+        return targets, nil
+    }
+
+    nodes, err := d.nodes.List(labels.Everything())
+    if err != nil {
+        return nil, err
+    }
+    for _, node := range nodes {
+        for _, addr := range node.Status.Addresses {
+            if addr.Type == v1.NodeInternalIP {
+                targets = append(targets, agentTarget{
+                    node:    node.Name,
+                    address: net.JoinHostPort(addr.Address, d.port),
+                })
+                break
+            }
+        }
+    }
+    return targets, nil
+}
@@ -0,0 +1,34 @@
+package plugin
+
+import (
+    "math"
+    "time"
+)
+
+// observe folds the node's current Capacity into its running estimate using
+// an exponentially weighted mean and variance, weighting the new sample by
+// alpha.
+func (hi *HostInfo) observe(alpha float64, now time.Time) {
+    if hi.Samples == 0 {
+        hi.CapacityMean = hi.Capacity
+        hi.CapacityVar = 0
+    } else {
+        diff := hi.Capacity - hi.CapacityMean
+        incr := alpha * diff
+        hi.CapacityMean += incr
+        hi.CapacityVar = (1 - alpha) * (hi.CapacityVar + diff*incr)
+    }
+    hi.Samples++
+    hi.LastUpdate = now
+}
+
+// capacityEstimate returns the mean and standard deviation of the node's
+// capacity at now. The variance grows by drift per second since the last
+// sample, so a node that stopped reporting becomes less certain over time.
+func capacityEstimate(hi *HostInfo, drift float64, now time.Time) (mean, std float64) {
+    variance := hi.CapacityVar
+    if !hi.LastUpdate.IsZero() {
+        variance += drift * now.Sub(hi.LastUpdate).Seconds()
+    }
+    return hi.CapacityMean, math.Sqrt(math.Max(variance, 0))
+}
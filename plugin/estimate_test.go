@@ -0,0 +1,76 @@
+package plugin
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestObserveFirstSample(t *testing.T) {
+    now := time.Unix(1000, 0)
+    hi := &HostInfo{Capacity: 7}
+    hi.observe(0.1, now)
+    if hi.CapacityMean != 7 || hi.CapacityVar != 0 || hi.Samples != 1 || !hi.LastUpdate.Equal(now) {
+        t.Errorf("after first sample: mean %v, var %v, samples %d, updated %v",
+            hi.CapacityMean, hi.CapacityVar, hi.Samples, hi.LastUpdate)
+    }
+}
+
+func TestObserveConverges(t *testing.T) {
+    now := time.Unix(1000, 0)
+
+    // A steady signal pulls the mean to it and the variance to zero.
+    hi := &HostInfo{Capacity: 0}
+    hi.observe(0.2, now)
+    hi.Capacity = 10
+    for i := 0; i < 100; i++ {
+        hi.observe(0.2, now)
+    }
+    if math.Abs(hi.CapacityMean-10) > 1e-6 || hi.CapacityVar > 1e-6 {
+        t.Errorf("steady signal: mean %v, var %v, want 10 and 0", hi.CapacityMean, hi.CapacityVar)
+    }
+
+    // A signal alternating between 0 and 10 settles around its mean of 5
+    // with a variance near the sample variance of 25.
+    hi = &HostInfo{}
+    for i := 0; i < 1000; i++ {
+        hi.Capacity = float64(10 * (i % 2))
+        hi.observe(0.05, now)
+    }
+    if math.Abs(hi.CapacityMean-5) > 0.5 {
+        t.Errorf("alternating signal: mean %v, want about 5", hi.CapacityMean)
+    }
+    if math.Abs(hi.CapacityVar-25) > 2.5 {
+        t.Errorf("alternating signal: var %v, want about 25", hi.CapacityVar)
+    }
+}
+
+func TestCapacityEstimateDrift(t *testing.T) {
+    clk := clocktesting.NewFakePassiveClock(time.Unix(1000, 0))
+    hi := &HostInfo{Capacity: 4}
+    hi.observe(0.1, clk.Now())
+    hi.CapacityVar = 1
+
+    tests := []struct {
+        age     time.Duration
+        wantStd float64
+    }{
+        {0, 1},
+        {300 * time.Second, 2},
+        {2400 * time.Second, 5},
+    }
+    for _, tt := range tests {
+        clk.SetTime(hi.LastUpdate.Add(tt.age))
+        mean, std := capacityEstimate(hi, 0.01, clk.Now())
+        if mean != 4 || math.Abs(std-tt.wantStd) > 1e-9 {
+            t.Errorf("after %v: estimate %v±%v, want 4±%v", tt.age, mean, std, tt.wantStd)
+        }
+    }
+
+    // A node that has never been observed does not drift.
+    if _, std := capacityEstimate(&HostInfo{CapacityVar: 1}, 0.01, clk.Now()); std != 1 {
+        t.Errorf("unobserved node std = %v, want 1", std)
+    }
+}
@@ -0,0 +1,363 @@
+package plugin
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// seriesSample is a remote-write sample; ts is in milliseconds.
+type seriesSample struct {
+    value float64
+    ts    int64
+}
+
+// series is the latest two samples of one time series.
+type series struct {
+    labels  map[string]string
+    prev    seriesSample
+    last    seriesSample
+    hasPrev bool
+    seen    bool
+}
+
+// nodeSeries are a node's series, keyed by their label set.
+type nodeSeries map[string]*series
+
+// seriesExpr is a parsed expression over a node's series. eval returns false
+// if the expression has no value yet, e.g. a selector matching no series.
+type seriesExpr interface {
+    eval(ns nodeSeries) (float64, bool)
+    // metrics adds the metric names the expression reads to names.
+    metrics(names map[string]bool)
+}
+
+type numberExpr float64
+
+func (e numberExpr) eval(nodeSeries) (float64, bool) { return float64(e), true }
+func (e numberExpr) metrics(map[string]bool)         {}
+
+// selectorExpr is the sum of the latest samples of the matching series.
+type selectorExpr struct {
+    name     string
+    matchers map[string]string
+}
+
+func (e *selectorExpr) matches(s *series) bool {
+    if s.labels[metricNameLabel] != e.name {
+        return false
+    }
+    for k, v := range e.matchers {
+        if s.labels[k] != v {
+            return false
+        }
+    }
+    return true
+}
+
+func (e *selectorExpr) eval(ns nodeSeries) (float64, bool) {
+    var sum float64
+    found := false
+    for _, s := range ns {
+        if e.matches(s) {
+            sum += s.last.value
+            found = true
+        }
+    }
+    return sum, found
+}
+
+func (e *selectorExpr) metrics(names map[string]bool) { names[e.name] = true }
+
+// rateExpr is the summed per-second rate of the matching counters, from
+// their latest two samples.
+type rateExpr struct {
+    sel *selectorExpr
+}
+
+func (e *rateExpr) eval(ns nodeSeries) (float64, bool) {
+    var sum float64
+    found := false
+    for _, s := range ns {
+        if !e.sel.matches(s) || !s.hasPrev || s.last.ts <= s.prev.ts {
+            continue
+        }
+        delta := s.last.value - s.prev.value
+        if delta < 0 {
+            // The counter was reset.
+            delta = s.last.value
+        }
+        sum += delta / (float64(s.last.ts-s.prev.ts) / 1000)
+        found = true
+    }
+    return sum, found
+}
+
+func (e *rateExpr) metrics(names map[string]bool) { e.sel.metrics(names) }
+
+type binaryExpr struct {
+    op       byte
+    lhs, rhs seriesExpr
+}
+
+func (e *binaryExpr) eval(ns nodeSeries) (float64, bool) {
+    l, ok := e.lhs.eval(ns)
+    if !ok {
+        return 0, false
+    }
+    r, ok := e.rhs.eval(ns)
+    if !ok {
+        return 0, false
+    }
+    switch e.op {
+    case '+':
+        return l + r, true
+    case '-':
+        return l - r, true
+    case '*':
+        return l * r, true
+    default:
+        if r == 0 {
+            return 0, false
+        }
+        return l / r, true
+    }
+}
+
+func (e *binaryExpr) metrics(names map[string]bool) {
+    e.lhs.metrics(names)
+    e.rhs.metrics(names)
+}
+
+// extremumExpr is min or max of its arguments.
+type extremumExpr struct {
+    max  bool
+    args []seriesExpr
+}
+
+func (e *extremumExpr) eval(ns nodeSeries) (float64, bool) {
+    var res float64
+    for i, arg := range e.args {
+        v, ok := arg.eval(ns)
+        if !ok {
+            return 0, false
+        }
+        if i == 0 || (e.max && v > res) || (!e.max && v < res) {
+            res = v
+        }
+    }
+    return res, true
+}
+
+func (e *extremumExpr) metrics(names map[string]bool) {
+    for _, arg := range e.args {
+        arg.metrics(names)
+    }
+}
+
+// parseSeriesExpr parses an expression of numbers, selectors such as
+// node_load1 or node_pressure_cpu_waiting_seconds_total{job="node"}, the
+// operators + - * / with parentheses, and the functions rate(selector),
+// min(...) and max(...).
+func parseSeriesExpr(s string) (seriesExpr, error) {
+    p := &exprParser{src: s}
+    e, err := p.expr()
+    if err != nil {
+        return nil, err
+    }
+    if p.skipSpace(); p.pos < len(p.src) {
+        return nil, p.errorf("unexpected %q", p.src[p.pos:])
+    }
+    return e, nil
+}
+
+type exprParser struct {
+    src string
+    pos int
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+    return fmt.Errorf("expression %q at offset %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpace() {
+    for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+        p.pos++
+    }
+}
+
+// peek skips spaces and returns the next byte, or 0 at the end.
+func (p *exprParser) peek() byte {
+    p.skipSpace()
+    if p.pos == len(p.src) {
+        return 0
+    }
+    return p.src[p.pos]
+}
+
+func (p *exprParser) expect(c byte) error {
+    if p.peek() != c {
+        return p.errorf("expected %q", c)
+    }
+    p.pos++
+    return nil
+}
+
+func (p *exprParser) expr() (seriesExpr, error) {
+    lhs, err := p.term()
+    if err != nil {
+        return nil, err
+    }
+    for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+        p.pos++
+        rhs, err := p.term()
+        if err != nil {
+            return nil, err
+        }
+        lhs = &binaryExpr{op: op, lhs: lhs, rhs: rhs}
+    }
+    return lhs, nil
+}
+
+func (p *exprParser) term() (seriesExpr, error) {
+    lhs, err := p.unary()
+    if err != nil {
+        return nil, err
+    }
+    for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+        p.pos++
+        rhs, err := p.unary()
+        if err != nil {
+            return nil, err
+        }
+        lhs = &binaryExpr{op: op, lhs: lhs, rhs: rhs}
+    }
+    return lhs, nil
+}
+
+func (p *exprParser) unary() (seriesExpr, error) {
+    if p.peek() == '-' {
+        p.pos++
+        e, err := p.unary()
+        if err != nil {
+            return nil, err
+        }
+        return &binaryExpr{op: '-', lhs: numberExpr(0), rhs: e}, nil
+    }
+    return p.primary()
+}
+
+func (p *exprParser) primary() (seriesExpr, error) {
+    c := p.peek()
+    switch {
+    case c == '(':
+        p.pos++
+        e, err := p.expr()
+        if err != nil {
+            return nil, err
+        }
+        return e, p.expect(')')
+    case c == '.' || (c >= '0' && c <= '9'):
+        return p.number()
+    case isIdentStart(c):
+    default:
+        return nil, p.errorf("unexpected %q", string(c))
+    }
+
+    name := p.ident()
+    if p.peek() != '(' {
+        return p.selector(name)
+    }
+    p.pos++
+    switch name {
+    case "rate":
+        sel, err := p.selector(p.ident())
+        if err != nil {
+            return nil, err
+        }
+        if sel.name == "" {
+            return nil, p.errorf("rate takes a selector")
+        }
+        return &rateExpr{sel: sel}, p.expect(')')
+    case "min", "max":
+        e := &extremumExpr{max: name == "max"}
+        for {
+            arg, err := p.expr()
+            if err != nil {
+                return nil, err
+            }
+            e.args = append(e.args, arg)
+            if p.peek() != ',' {
+                break
+            }
+            p.pos++
+        }
+        return e, p.expect(')')
+    default:
+        return nil, p.errorf("unknown function %q", name)
+    }
+}
+
+func (p *exprParser) number() (seriesExpr, error) {
+    start := p.pos
+    for p.pos < len(p.src) && strings.IndexByte("0123456789.eE", p.src[p.pos]) >= 0 {
+        p.pos++
+        // An exponent may be signed, as in 1e-3.
+        if c := p.src[p.pos-1]; (c == 'e' || c == 'E') && p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+            p.pos++
+        }
+    }
+    v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+    if err != nil {
+        return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+    }
+    return numberExpr(v), nil
+}
+
+func isIdentStart(c byte) bool {
+    return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (p *exprParser) ident() string {
+    p.skipSpace()
+    start := p.pos
+    if p.pos == len(p.src) || !isIdentStart(p.src[p.pos]) {
+        return ""
+    }
+    for p.pos < len(p.src) && (isIdentStart(p.src[p.pos]) || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+        p.pos++
+    }
+    return p.src[start:p.pos]
+}
+
+func (p *exprParser) selector(name string) (*selectorExpr, error) {
+    sel := &selectorExpr{name: name, matchers: map[string]string{}}
+    if p.peek() != '{' {
+        return sel, nil
+    }
+    p.pos++
+    for p.peek() != '}' {
+        label := p.ident()
+        if label == "" {
+            return nil, p.errorf("expected a label name")
+        }
+        if err := p.expect('='); err != nil {
+            return nil, err
+        }
+        if p.peek() != '"' {
+            return nil, p.errorf("expected a quoted label value")
+        }
+        end := strings.IndexByte(p.src[p.pos+1:], '"')
+        if end < 0 {
+            return nil, p.errorf("unterminated label value")
+        }
+        sel.matchers[label] = p.src[p.pos+1 : p.pos+1+end]
+        p.pos += end + 2
+        if p.peek() == ',' {
+            p.pos++
+        }
+    }
+    p.pos++
+    return sel, nil
+}
@@ -0,0 +1,120 @@
+package plugin
+
+import (
+    "math"
+    "testing"
+)
+
+// testSeries builds a nodeSeries from series given as metric name, labels
+// and their previous and latest samples.
+func testSeries(series ...*series) nodeSeries {
+    ns := nodeSeries{}
+    for _, s := range series {
+        s.seen = true
+        ns[seriesKey(s.labels)] = s
+    }
+    return ns
+}
+
+func TestParseSeriesExpr(t *testing.T) {
+    ns := testSeries(
+        &series{
+            labels: map[string]string{metricNameLabel: "load", "cpu": "0"},
+            last:   seriesSample{value: 2, ts: 2000},
+        },
+        &series{
+            labels: map[string]string{metricNameLabel: "load", "cpu": "1"},
+            last:   seriesSample{value: 4, ts: 2000},
+        },
+        &series{
+            labels:  map[string]string{metricNameLabel: "waiting", "job": "node"},
+            prev:    seriesSample{value: 10, ts: 1000},
+            last:    seriesSample{value: 15, ts: 3000},
+            hasPrev: true,
+        },
+        &series{
+            // Reset between the two samples.
+            labels:  map[string]string{metricNameLabel: "restarts"},
+            prev:    seriesSample{value: 100, ts: 1000},
+            last:    seriesSample{value: 3, ts: 2000},
+            hasPrev: true,
+        },
+    )
+
+    tests := []struct {
+        expr string
+        want float64
+        // ok is false if the expression has no value.
+        ok bool
+    }{
+        {expr: "1 + 2 * 3", want: 7, ok: true},
+        {expr: "(1 + 2) * 3", want: 9, ok: true},
+        {expr: "8 / 4 / 2", want: 1, ok: true},
+        {expr: "10 - 4 - 3", want: 3, ok: true},
+        {expr: "-2 * 3", want: -6, ok: true},
+        {expr: "1 - -1", want: 2, ok: true},
+        {expr: "--3", want: 3, ok: true},
+        {expr: "1e-3", want: 0.001, ok: true},
+        {expr: "2.5E+2", want: 250, ok: true},
+        {expr: ".5e1", want: 5, ok: true},
+        {expr: "load", want: 6, ok: true},
+        {expr: `load{cpu="1"}`, want: 4, ok: true},
+        {expr: `load{cpu="0", }`, want: 2, ok: true},
+        {expr: `load{cpu="2"}`, ok: false},
+        {expr: "missing + 1", ok: false},
+        {expr: `rate(waiting{job="node"})`, want: 2.5, ok: true},
+        {expr: "rate(load)", ok: false},
+        {expr: "rate(restarts)", want: 3, ok: true},
+        {expr: "1 - rate(waiting) / 5", want: 0.5, ok: true},
+        {expr: "min(load, 3, 5)", want: 3, ok: true},
+        {expr: `max(load{cpu="0"}, 1)`, want: 2, ok: true},
+        {expr: "1 / 0", ok: false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.expr, func(t *testing.T) {
+            e, err := parseSeriesExpr(tt.expr)
+            if err != nil {
+                t.Fatalf("parseSeriesExpr: %v", err)
+            }
+            got, ok := e.eval(ns)
+            if ok != tt.ok {
+                t.Fatalf("eval ok = %v, want %v", ok, tt.ok)
+            }
+            if ok && math.Abs(got-tt.want) > 1e-12 {
+                t.Errorf("eval = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseSeriesExprErrors(t *testing.T) {
+    for _, expr := range []string{
+        "",
+        "1 +",
+        "(1 + 2",
+        "1e",
+        "1 2",
+        "rate(1)",
+        "avg(load)",
+        `load{cpu=0}`,
+        `load{cpu="0}`,
+        "load{=\"0\"}",
+        "1 $ 2",
+    } {
+        if _, err := parseSeriesExpr(expr); err == nil {
+            t.Errorf("parseSeriesExpr(%q) succeeded, want an error", expr)
+        }
+    }
+}
+
+func TestSeriesExprMetrics(t *testing.T) {
+    e, err := parseSeriesExpr(`max(a, rate(b{x="y"})) - c * 2`)
+    if err != nil {
+        t.Fatal(err)
+    }
+    names := map[string]bool{}
+    e.metrics(names)
+    if len(names) != 3 || !names["a"] || !names["b"] || !names["c"] {
+        t.Errorf("metrics = %v, want a, b and c", names)
+    }
+}
@@ -0,0 +1,243 @@
+package plugin
+
+import (
+    "sync"
+
+    "k8s.io/component-base/metrics"
+    "k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "pronto"
+
+// Filter verdicts. The rejections double as label values for
+// filter_rejections_total.
+const (
+    verdictFits      = "fits"
+    rejectNoData     = "no_data"
+    rejectStale      = "stale"
+    rejectNoCapacity = "insufficient_capacity"
+)
+
+// Label values for signal_samples_total.
+const (
+    sampleAccepted     = "accepted"
+    sampleMissingNode  = "missing_node"
+    sampleInvalidValue = "invalid_value"
+    sampleStale        = "stale"
+)
+
+// Label values for reservation_duration_seconds.
+const (
+    reservationStarted    = "started"
+    reservationDeleted    = "deleted"
+    reservationUnreserved = "unreserved"
+)
+
+var (
+    // placementsPerSignal measures herding: how many pods were placed on a
+    // node between two of its signals, i.e. while every scheduling decision
+    // for that node was based on the same reading.
+    placementsPerSignal = metrics.NewHistogram(
+        &metrics.HistogramOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "placements_per_signal_interval",
+            Help:           "Number of pods placed on a node between two consecutive signals from it.",
+            Buckets:        []float64{0, 1, 2, 4, 8, 16, 32, 64},
+            StabilityLevel: metrics.ALPHA,
+        })
+
+    shadowDecisions = metrics.NewCounterVec(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "shadow_decisions_total",
+            Help:           "Pods bound by other schedulers, by whether Pronto would have chosen the same node (agree), another node (disagree) or no node (unschedulable).",
+            StabilityLevel: metrics.ALPHA,
+        }, []string{"result"})
+
+    shadowCapacityViolations = metrics.NewCounter(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "shadow_capacity_violations_total",
+            Help:           "Pods bound by other schedulers to nodes that Pronto's Filter would have rejected for a stale signal or insufficient capacity.",
+            StabilityLevel: metrics.ALPHA,
+        })
+
+    shadowNoDataPlacements = metrics.NewCounter(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "shadow_no_data_total",
+            Help:           "Pods bound by other schedulers to nodes Pronto has no signal for.",
+            StabilityLevel: metrics.ALPHA,
+        })
+
+    signalSamples = metrics.NewCounterVec(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "signal_samples_total",
+            Help:           "Signal samples received from node agents, by whether they were accepted or the reason they were rejected.",
+            StabilityLevel: metrics.ALPHA,
+        }, []string{"result"})
+
+    streamsOpened = metrics.NewCounter(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "signal_streams_opened_total",
+            Help:           "Signal streams opened by node agents.",
+            StabilityLevel: metrics.ALPHA,
+        })
+
+    streamsClosed = metrics.NewCounterVec(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "signal_streams_closed_total",
+            Help:           "Signal streams closed, by whether the agent closed them cleanly (eof) or they failed (error).",
+            StabilityLevel: metrics.ALPHA,
+        }, []string{"reason"})
+
+    scrapeFailures = metrics.NewCounter(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "scrape_failures_total",
+            Help:           "Polls of node agents by the scrape source that failed or timed out.",
+            StabilityLevel: metrics.ALPHA,
+        })
+
+    filterRejections = metrics.NewCounterVec(
+        &metrics.CounterOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "filter_rejections_total",
+            Help:           "Nodes rejected by Pronto's Filter, by reason.",
+            StabilityLevel: metrics.ALPHA,
+        }, []string{"reason"})
+
+    signalAge = metrics.NewHistogram(
+        &metrics.HistogramOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "signal_age_seconds",
+            Help:           "Time between a node's signal being ingested and it being used by Filter.",
+            Buckets:        metrics.ExponentialBuckets(0.01, 2, 15),
+            StabilityLevel: metrics.ALPHA,
+        })
+
+    reservationDuration = metrics.NewHistogramVec(
+        &metrics.HistogramOpts{
+            Subsystem:      metricsSubsystem,
+            Name:           "reservation_duration_seconds",
+            Help:           "Time a pod held a reservation, by how it was released: the pod started, was deleted, or was unreserved after a failed scheduling cycle.",
+            Buckets:        metrics.ExponentialBuckets(0.01, 2, 15),
+            StabilityLevel: metrics.ALPHA,
+        }, []string{"outcome"})
+)
+
+// hostMetrics exports per-node gauges from prontoState at scrape time. It is
+// registered once with the legacy registry, so it exports a single plugin
+// instance: the one that called track last. This assumes Pronto runs in one
+// scheduler profile; with several, only the last profile to start is
+// exported. The simulator creates one instance after another, and each run
+// takes over the series from the previous one.
+var hostMetrics = &hostCollector{}
+
+var (
+    hostSignalDesc = metrics.NewDesc(
+        metricsSubsystem+"_node_signal",
+        "Last signal reported for the node.",
+        []string{"node"}, nil, metrics.ALPHA, "")
+    hostCapacityDesc = metrics.NewDesc(
+        metricsSubsystem+"_node_capacity",
+        "Last capacity reported for the node.",
+        []string{"node"}, nil, metrics.ALPHA, "")
+    hostOverprovisionDesc = metrics.NewDesc(
+        metricsSubsystem+"_node_overprovision",
+        "Last overprovision reported for the node.",
+        []string{"node"}, nil, metrics.ALPHA, "")
+    hostReservedDesc = metrics.NewDesc(
+        metricsSubsystem+"_node_reserved",
+        "Pods reserved on the node and not yet running.",
+        []string{"node"}, nil, metrics.ALPHA, "")
+    hostStalenessDesc = metrics.NewDesc(
+        metricsSubsystem+"_node_staleness_seconds",
+        "Time since the node's last signal.",
+        []string{"node"}, nil, metrics.ALPHA, "")
+    // herdingMaxPlacementsDesc is the herding in the current signal
+    // intervals, unlike the all-time placements_per_signal_interval.
+    herdingMaxPlacementsDesc = metrics.NewDesc(
+        metricsSubsystem+"_herding_max_placements",
+        "Largest number of pods placed on a single node within its current or last completed signal interval.",
+        nil, nil, metrics.ALPHA, "")
+)
+
+type hostCollector struct {
+    metrics.BaseStableCollector
+
+    mu    sync.Mutex
+    state *prontoState
+}
+
+// track exports ps in place of the state tracked before.
+func (c *hostCollector) track(ps *prontoState) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.state = ps
+}
+
+// untrack stops exporting ps, unless another state has replaced it.
+func (c *hostCollector) untrack(ps *prontoState) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.state == ps {
+        c.state = nil
+    }
+}
+
+func (c *hostCollector) DescribeWithStability(ch chan<- *metrics.Desc) {
+    ch <- hostSignalDesc
+    ch <- hostCapacityDesc
+    ch <- hostOverprovisionDesc
+    ch <- hostReservedDesc
+    ch <- hostStalenessDesc
+    ch <- herdingMaxPlacementsDesc
+}
+
+func (c *hostCollector) CollectWithStability(ch chan<- metrics.Metric) {
+    c.mu.Lock()
+    ps := c.state
+    c.mu.Unlock()
+    if ps == nil {
+        return
+    }
+
+    now := ps.clock.Now()
+    maxPlaced := 0
+    for name, hostInfo := range ps.hosts() {
+        maxPlaced = max(maxPlaced, hostInfo.PlacedSinceSignal, hostInfo.PlacedLastInterval)
+        ch <- metrics.NewLazyConstMetric(hostSignalDesc, metrics.GaugeValue, hostInfo.Signal, name)
+        ch <- metrics.NewLazyConstMetric(hostCapacityDesc, metrics.GaugeValue, hostInfo.Capacity, name)
+        ch <- metrics.NewLazyConstMetric(hostOverprovisionDesc, metrics.GaugeValue, hostInfo.Overprovision, name)
+        ch <- metrics.NewLazyConstMetric(hostReservedDesc, metrics.GaugeValue, float64(hostInfo.Reserved), name)
+        if !hostInfo.LastUpdate.IsZero() {
+            ch <- metrics.NewLazyConstMetric(hostStalenessDesc, metrics.GaugeValue, now.Sub(hostInfo.LastUpdate).Seconds(), name)
+        }
+    }
+    ch <- metrics.NewLazyConstMetric(herdingMaxPlacementsDesc, metrics.GaugeValue, float64(maxPlaced))
+}
+
+var registerMetrics sync.Once
+
+// RegisterMetrics registers the Pronto metrics with the scheduler's legacy
+// registry, so they are served on its /metrics endpoint.
+func RegisterMetrics() {
+    registerMetrics.Do(func() {
+        legacyregistry.MustRegister(placementsPerSignal)
+        legacyregistry.MustRegister(shadowDecisions)
+        legacyregistry.MustRegister(shadowCapacityViolations)
+        legacyregistry.MustRegister(shadowNoDataPlacements)
+        legacyregistry.MustRegister(signalSamples)
+        legacyregistry.MustRegister(streamsOpened)
+        legacyregistry.MustRegister(streamsClosed)
+        legacyregistry.MustRegister(scrapeFailures)
+        legacyregistry.MustRegister(filterRejections)
+        legacyregistry.MustRegister(signalAge)
+        legacyregistry.MustRegister(reservationDuration)
+        legacyregistry.CustomMustRegister(hostMetrics)
+    })
+}
@@ -0,0 +1,89 @@
+package plugin
+
+import (
+    "strings"
+    "testing"
+    "time"
+
+    "k8s.io/component-base/metrics/testutil"
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+var hostMetricNames = []string{
+    "pronto_node_signal",
+    "pronto_node_capacity",
+    "pronto_node_reserved",
+    "pronto_node_staleness_seconds",
+    "pronto_herding_max_placements",
+}
+
+func TestHostCollector(t *testing.T) {
+    start := time.Unix(1000, 0)
+    clk := clocktesting.NewFakePassiveClock(start)
+    ps := newTestState()
+    ps.clock = clk
+    ps.UpdateHostInfo("a", WithSignal(0.25), WithCapacity(4))
+    ps.UpdateHostInfo("b", WithSignal(0.5), WithCapacity(2))
+    ps.mu.Lock()
+    ps.HostReservations["a"].Reserved = 2
+    ps.HostReservations["a"].PlacedSinceSignal = 3
+    ps.mu.Unlock()
+    clk.SetTime(start.Add(30 * time.Second))
+
+    c := &hostCollector{}
+    c.track(ps)
+    want := `
+# HELP pronto_herding_max_placements [ALPHA] Largest number of pods placed on a single node within its current or last completed signal interval.
+# TYPE pronto_herding_max_placements gauge
+pronto_herding_max_placements 3
+# HELP pronto_node_capacity [ALPHA] Last capacity reported for the node.
+# TYPE pronto_node_capacity gauge
+pronto_node_capacity{node="a"} 4
+pronto_node_capacity{node="b"} 2
+# HELP pronto_node_reserved [ALPHA] Pods reserved on the node and not yet running.
+# TYPE pronto_node_reserved gauge
+pronto_node_reserved{node="a"} 2
+pronto_node_reserved{node="b"} 0
+# HELP pronto_node_signal [ALPHA] Last signal reported for the node.
+# TYPE pronto_node_signal gauge
+pronto_node_signal{node="a"} 0.25
+pronto_node_signal{node="b"} 0.5
+# HELP pronto_node_staleness_seconds [ALPHA] Time since the node's last signal.
+# TYPE pronto_node_staleness_seconds gauge
+pronto_node_staleness_seconds{node="a"} 30
+pronto_node_staleness_seconds{node="b"} 30
+`
+    if err := testutil.CustomCollectAndCompare(c, strings.NewReader(want), hostMetricNames...); err != nil {
+        t.Error(err)
+    }
+
+    // A later instance takes over the series, and the earlier one
+    // stopping does not clear them. A collector can only be registered
+    // once, so each comparison uses a new one.
+    next := newTestState()
+    next.clock = clk
+    next.UpdateHostInfo("c", WithCapacity(1))
+    c = &hostCollector{}
+    c.track(ps)
+    c.track(next)
+    c.untrack(ps)
+    want = `
+# HELP pronto_herding_max_placements [ALPHA] Largest number of pods placed on a single node within its current or last completed signal interval.
+# TYPE pronto_herding_max_placements gauge
+pronto_herding_max_placements 0
+# HELP pronto_node_capacity [ALPHA] Last capacity reported for the node.
+# TYPE pronto_node_capacity gauge
+pronto_node_capacity{node="c"} 1
+`
+    if err := testutil.CustomCollectAndCompare(c, strings.NewReader(want),
+        "pronto_herding_max_placements", "pronto_node_capacity"); err != nil {
+        t.Error(err)
+    }
+
+    c = &hostCollector{}
+    c.track(next)
+    c.untrack(next)
+    if err := testutil.CustomCollectAndCompare(c, strings.NewReader(""), hostMetricNames...); err != nil {
+        t.Error(err)
+    }
+}
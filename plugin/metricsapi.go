@@ -0,0 +1,148 @@
+package plugin
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "time"
+
+    "github.com/go-logr/logr"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s.io/apimachinery/pkg/util/wait"
+    "k8s.io/client-go/dynamic"
+    corelisters "k8s.io/client-go/listers/core/v1"
+    "k8s.io/client-go/rest"
+)
+
+// nodeMetricsGVR identifies the node metrics served by metrics-server.
+var nodeMetricsGVR = schema.GroupVersionResource{
+    Group:    "metrics.k8s.io",
+    Version:  "v1beta1",
+    Resource: "nodes",
+}
+
+const metricsSourceName = "metrics"
+
+// metricsSource is a fallback for nodes without a Pronto agent. It derives
+// a coarse signal from the Metrics API: the signal is the node's CPU or
+// memory utilization, whichever is higher, and the capacity is the number
+// of PodCPU/PodMemory-sized pods that fit in its unused allocatable
+// resources. Its samples are marked LowConfidence.
+type metricsSource struct {
+    state  *prontoState
+    cfg    MetricsSource
+    config *rest.Config
+    nodes  corelisters.NodeLister
+    logger logr.Logger
+    // seen holds the timestamp of the last metrics ingested per node.
+    seen map[string]time.Time
+}
+
+func newMetricsSource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    return &metricsSource{
+        state:  &pl.prontoState,
+        cfg:    pl.args.MetricsSource,
+        config: pl.handle.KubeConfig(),
+        nodes:  pl.handle.SharedInformerFactory().Core().V1().Nodes().Lister(),
+        logger: logger,
+        seen:   make(map[string]time.Time),
+    }, nil
+}
+
+func (src *metricsSource) Name() string { return metricsSourceName }
+
+func (src *metricsSource) Start(ctx context.Context) error {
+    client, err := dynamic.NewForConfig(src.config)
+    if err != nil {
+        return fmt.Errorf("creating dynamic client: %w", err)
+    }
+    go wait.UntilWithContext(ctx, func(ctx context.Context) {
+        if err := src.poll(ctx, client); err != nil {
+            src.logger.Error(err, "Polling node metrics")
+        }
+    }, src.cfg.Interval.Duration)
+    return nil
+}
+
+func (src *metricsSource) poll(ctx context.Context, client dynamic.Interface) error {
+    list, err := client.Resource(nodeMetricsGVR).List(ctx, metav1.ListOptions{})
+    if err != nil {
+        return err
+    }
+    live := make(map[string]bool, len(list.Items))
+    for i := range list.Items {
+        u := &list.Items[i]
+        live[u.GetName()] = true
+        if err := src.ingest(u); err != nil {
+            signalSamples.WithLabelValues(sampleInvalidValue).Inc()
+            src.logger.V(4).Info("Skipping node metrics", "node", u.GetName(), "err", err)
+        }
+    }
+    for name := range src.seen {
+        if !live[name] {
+            delete(src.seen, name)
+        }
+    }
+    return nil
+}
+
+func (src *metricsSource) ingest(u *unstructured.Unstructured) error {
+    name := u.GetName()
+    ts, _, err := unstructured.NestedString(u.Object, "timestamp")
+    if err != nil {
+        return err
+    }
+    timestamp, err := time.Parse(time.RFC3339, ts)
+    if err != nil {
+        return fmt.Errorf("parsing timestamp: %w", err)
+    }
+    // metrics-server refreshes less often than it may be polled.
+    if seen, ok := src.seen[name]; ok && !timestamp.After(seen) {
+        return nil
+    }
+
+    node, err := src.nodes.Get(name)
+    if err != nil {
+        return err
+    }
+    cpu, err := nestedQuantity(u, "usage", "cpu")
+    if err != nil {
+        return err
+    }
+    memory, err := nestedQuantity(u, "usage", "memory")
+    if err != nil {
+        return err
+    }
+    allocCPU := node.Status.Allocatable.Cpu().MilliValue()
+    allocMemory := node.Status.Allocatable.Memory().Value()
+    if allocCPU <= 0 || allocMemory <= 0 {
+        return fmt.Errorf("node has no allocatable cpu or memory")
+    }
+
+    freeCPU := float64(allocCPU - cpu.MilliValue())
+    freeMemory := float64(allocMemory - memory.Value())
+    signal := math.Max(float64(cpu.MilliValue())/float64(allocCPU), float64(memory.Value())/float64(allocMemory))
+    capacity := math.Max(0, math.Min(freeCPU/float64(src.cfg.PodCPU.MilliValue()), freeMemory/float64(src.cfg.PodMemory.Value())))
+
+    src.seen[name] = timestamp
+    signalSamples.WithLabelValues(sampleAccepted).Inc()
+    src.state.Ingest(metricsSourceName, name, WithCapacity(capacity),
+        WithSignal(signal),
+        WithOverprovision(0),
+        WithLowConfidence())
+    return nil
+}
+
+func nestedQuantity(u *unstructured.Unstructured, fields ...string) (resource.Quantity, error) {
+    s, found, err := unstructured.NestedString(u.Object, fields...)
+    if err != nil {
+        return resource.Quantity{}, err
+    }
+    if !found {
+        return resource.Quantity{}, fmt.Errorf("%v is missing", fields)
+    }
+    return resource.ParseQuantity(s)
+}
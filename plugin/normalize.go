@@ -0,0 +1,162 @@
+package plugin
+
+import (
+    "fmt"
+    "math"
+    "sort"
+
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// normalizer rescales the raw scores of every node in place so that they fall
+// within [framework.MinNodeScore, framework.MaxNodeScore].
+type normalizer func(scores framework.NodeScoreList)
+
+func newNormalizer(n ScoreNormalizer) (normalizer, error) {
+    switch n.Type {
+    case MinMaxNormalizer:
+        return minMaxNormalize, nil
+    case RankNormalizer:
+        return rankNormalize, nil
+    case ZScoreNormalizer:
+        return zScoreNormalize(n.ZScoreClip), nil
+    case LogNormalizer:
+        return logNormalize, nil
+    case AbsoluteNormalizer:
+        return absoluteNormalize, nil
+    }
+    return nil, fmt.Errorf("unknown score normalizer %q", n.Type)
+}
+
+const scoreRange = framework.MaxNodeScore - framework.MinNodeScore
+
+func clampScore(score int64) int64 {
+    if score < framework.MinNodeScore {
+        return framework.MinNodeScore
+    }
+    if score > framework.MaxNodeScore {
+        return framework.MaxNodeScore
+    }
+    return score
+}
+
+// minMaxNormalize maps the lowest score to MinNodeScore and the highest to
+// MaxNodeScore. If every node has the same score they all get MinNodeScore.
+func minMaxNormalize(scores framework.NodeScoreList) {
+    // Find highest and lowest scores.
+    var highest int64 = -math.MaxInt64
+    var lowest int64 = math.MaxInt64
+    for _, nodeScore := range scores {
+        if nodeScore.Score > highest {
+            highest = nodeScore.Score
+        }
+        if nodeScore.Score < lowest {
+            lowest = nodeScore.Score
+        }
+    }
+
+    // Transform the highest to lowest score range to fit the framework's min to max node score range.
+    oldRange := highest - lowest
+    for i, nodeScore := range scores {
+        if oldRange == 0 {
+            scores[i].Score = framework.MinNodeScore
+        } else {
+            scores[i].Score = ((nodeScore.Score - lowest) * scoreRange / oldRange) + framework.MinNodeScore
+        }
+    }
+}
+
+// rankNormalize spaces the distinct scores evenly across the score range, so
+// only the ordering of nodes matters. Nodes with equal scores share a rank; if
+// there is a single distinct score every node gets MaxNodeScore.
+func rankNormalize(scores framework.NodeScoreList) {
+    distinct := make([]int64, 0, len(scores))
+    for _, nodeScore := range scores {
+        distinct = append(distinct, nodeScore.Score)
+    }
+    sort.Slice(distinct, func(i, j int) bool { return distinct[i] < distinct[j] })
+    n := 0
+    for i, s := range distinct {
+        if i == 0 || s != distinct[n-1] {
+            distinct[n] = s
+            n++
+        }
+    }
+    distinct = distinct[:n]
+
+    for i, nodeScore := range scores {
+        if n == 1 {
+            scores[i].Score = framework.MaxNodeScore
+            continue
+        }
+        rank := int64(sort.Search(n, func(j int) bool { return distinct[j] >= nodeScore.Score }))
+        scores[i].Score = rank*scoreRange/int64(n-1) + framework.MinNodeScore
+    }
+}
+
+// zScoreNormalize standardises the scores, clips them to [-clip, clip]
+// standard deviations and maps that interval onto the score range. Outliers
+// therefore cannot stretch the scale for everyone else. If every node has the
+// same score they all land in the middle of the range.
+func zScoreNormalize(clip float64) normalizer {
+    return func(scores framework.NodeScoreList) {
+        if len(scores) == 0 {
+            return
+        }
+        var mean float64
+        for _, nodeScore := range scores {
+            mean += float64(nodeScore.Score)
+        }
+        mean /= float64(len(scores))
+
+        var variance float64
+        for _, nodeScore := range scores {
+            d := float64(nodeScore.Score) - mean
+            variance += d * d
+        }
+        std := math.Sqrt(variance / float64(len(scores)))
+
+        for i, nodeScore := range scores {
+            var z float64
+            if std > 0 {
+                z = (float64(nodeScore.Score) - mean) / std
+            }
+            z = math.Max(-clip, math.Min(clip, z))
+            scores[i].Score = int64(math.Round((z+clip)/(2*clip)*float64(scoreRange))) + framework.MinNodeScore
+        }
+    }
+}
+
+// logNormalize is min-max scaling applied to log(1 + score - lowest), which
+// compresses large gaps between scores while keeping their order. If every
+// node has the same score they all get MinNodeScore, as with min-max.
+func logNormalize(scores framework.NodeScoreList) {
+    var highest int64 = -math.MaxInt64
+    var lowest int64 = math.MaxInt64
+    for _, nodeScore := range scores {
+        if nodeScore.Score > highest {
+            highest = nodeScore.Score
+        }
+        if nodeScore.Score < lowest {
+            lowest = nodeScore.Score
+        }
+    }
+
+    oldRange := math.Log1p(float64(highest - lowest))
+    for i, nodeScore := range scores {
+        if oldRange == 0 {
+            scores[i].Score = framework.MinNodeScore
+        } else {
+            scaled := math.Log1p(float64(nodeScore.Score-lowest)) / oldRange
+            scores[i].Score = int64(math.Round(scaled*float64(scoreRange))) + framework.MinNodeScore
+        }
+    }
+}
+
+// absoluteNormalize keeps the raw scores and only clamps them into the score
+// range, so a node's score does not depend on the other candidates.
+func absoluteNormalize(scores framework.NodeScoreList) {
+    for i, nodeScore := range scores {
+        scores[i].Score = clampScore(nodeScore.Score)
+    }
+}
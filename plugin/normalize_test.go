@@ -0,0 +1,82 @@
+package plugin
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func nodeScores(scores ...int64) framework.NodeScoreList {
+    list := make(framework.NodeScoreList, len(scores))
+    for i, s := range scores {
+        list[i] = framework.NodeScore{Name: fmt.Sprintf("n%d", i), Score: s}
+    }
+    return list
+}
+
+func TestNormalizers(t *testing.T) {
+    tests := []struct {
+        name       string
+        normalizer ScoreNormalizer
+        scores     []int64
+        want       []int64
+    }{
+        {"minmax equal", ScoreNormalizer{Type: MinMaxNormalizer}, []int64{5, 5, 5}, []int64{0, 0, 0}},
+        {"minmax single", ScoreNormalizer{Type: MinMaxNormalizer}, []int64{7}, []int64{0}},
+        {"minmax negative", ScoreNormalizer{Type: MinMaxNormalizer}, []int64{-50, 0, 50}, []int64{0, 50, 100}},
+        {"minmax spread", ScoreNormalizer{Type: MinMaxNormalizer}, []int64{10, 20, 40}, []int64{0, 33, 100}},
+
+        {"rank equal", ScoreNormalizer{Type: RankNormalizer}, []int64{5, 5, 5}, []int64{100, 100, 100}},
+        {"rank single", ScoreNormalizer{Type: RankNormalizer}, []int64{7}, []int64{100}},
+        {"rank negative", ScoreNormalizer{Type: RankNormalizer}, []int64{-50, 0, 50}, []int64{0, 50, 100}},
+        // Tied nodes share a rank, and ranks are evenly spaced whatever
+        // the gaps between scores.
+        {"rank ties", ScoreNormalizer{Type: RankNormalizer}, []int64{1000, 20, 10, 20}, []int64{100, 50, 0, 50}},
+
+        {"zscore equal", ScoreNormalizer{Type: ZScoreNormalizer, ZScoreClip: 3}, []int64{5, 5, 5}, []int64{50, 50, 50}},
+        {"zscore single", ScoreNormalizer{Type: ZScoreNormalizer, ZScoreClip: 3}, []int64{7}, []int64{50}},
+        {"zscore negative", ScoreNormalizer{Type: ZScoreNormalizer, ZScoreClip: 3}, []int64{-50, 0, 50}, []int64{30, 50, 70}},
+        // The outlier is 3 standard deviations above the mean.
+        {"zscore unclipped", ScoreNormalizer{Type: ZScoreNormalizer, ZScoreClip: 3},
+            []int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 100}, []int64{44, 44, 44, 44, 44, 44, 44, 44, 44, 100}},
+        {"zscore clipped", ScoreNormalizer{Type: ZScoreNormalizer, ZScoreClip: 1},
+            []int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 100}, []int64{33, 33, 33, 33, 33, 33, 33, 33, 33, 100}},
+
+        {"log equal", ScoreNormalizer{Type: LogNormalizer}, []int64{5, 5, 5}, []int64{0, 0, 0}},
+        {"log single", ScoreNormalizer{Type: LogNormalizer}, []int64{7}, []int64{0}},
+        {"log negative", ScoreNormalizer{Type: LogNormalizer}, []int64{-10, -9, 90}, []int64{0, 15, 100}},
+
+        {"absolute equal", ScoreNormalizer{Type: AbsoluteNormalizer}, []int64{5, 5}, []int64{5, 5}},
+        {"absolute single", ScoreNormalizer{Type: AbsoluteNormalizer}, []int64{7}, []int64{7}},
+        {"absolute negative", ScoreNormalizer{Type: AbsoluteNormalizer}, []int64{-5, 50, 150}, []int64{0, 50, 100}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            normalize, err := newNormalizer(tt.normalizer)
+            if err != nil {
+                t.Fatal(err)
+            }
+            scores := nodeScores(tt.scores...)
+            normalize(scores)
+            want := nodeScores(tt.want...)
+            if !reflect.DeepEqual(scores, want) {
+                t.Errorf("normalized %v to %v, want %v", tt.scores, scores, want)
+            }
+        })
+    }
+}
+
+func TestNormalizersEmpty(t *testing.T) {
+    for _, typ := range []ScoreNormalizerType{MinMaxNormalizer, RankNormalizer, ZScoreNormalizer, LogNormalizer, AbsoluteNormalizer} {
+        normalize, err := newNormalizer(ScoreNormalizer{Type: typ, ZScoreClip: 3})
+        if err != nil {
+            t.Fatal(err)
+        }
+        normalize(framework.NodeScoreList{})
+    }
+    if _, err := newNormalizer(ScoreNormalizer{Type: "Sigmoid"}); err == nil {
+        t.Error("newNormalizer accepted an unknown type")
+    }
+}
@@ -0,0 +1,208 @@
+package plugin
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+    orcaservicepb "github.com/cncf/xds/go/xds/service/orca/v3"
+    "github.com/go-logr/logr"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/protobuf/types/known/durationpb"
+    "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const orcaSourceName = "orca"
+
+// Keys of the ORCA load report fields an OrcaMetric can name. Named
+// utilizations and metrics are prefixed with their map.
+const (
+    orcaCPUUtilization         = "cpu_utilization"
+    orcaMemUtilization         = "mem_utilization"
+    orcaApplicationUtilization = "application_utilization"
+    orcaUtilizationPrefix      = "utilization."
+    orcaNamedMetricPrefix      = "named_metrics."
+)
+
+// orcaSource is an ORCA out-of-band client: it opens a StreamCoreMetrics
+// stream to every node agent and maps the load reports onto the node's
+// signal, so any backend with an ORCA implementation can feed Pronto.
+type orcaSource struct {
+    state     *prontoState
+    cfg       OrcaSource
+    discovery *agentDiscovery
+    logger    logr.Logger
+    // streams cancels the stream to each target. Only resync touches it.
+    streams map[agentTarget]context.CancelFunc
+}
+
+func newOrcaSource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    src := &orcaSource{
+        state:   &pl.prontoState,
+        cfg:     pl.args.OrcaSource,
+        logger:  logger,
+        streams: make(map[agentTarget]context.CancelFunc),
+    }
+    discovery, err := newAgentDiscovery(pl.handle, src.cfg.PodSelector, src.cfg.Namespace, src.cfg.Port)
+    if err != nil {
+        return nil, fmt.Errorf("orcaSource: %w", err)
+    }
+    src.discovery = discovery
+    return src, nil
+}
+
+func (src *orcaSource) Name() string { return orcaSourceName }
+
+// Start reconciles the streams with the discovered agents every
+// ResyncPeriod until ctx is done.
+func (src *orcaSource) Start(ctx context.Context) error {
+    go wait.UntilWithContext(ctx, src.resync, src.cfg.ResyncPeriod.Duration)
+    return nil
+}
+
+func (src *orcaSource) resync(ctx context.Context) {
+    targets, err := src.discovery.targets()
+    if err != nil {
+        src.logger.Error(err, "Listing ORCA targets")
+        return
+    }
+    live := make(map[agentTarget]bool, len(targets))
+    for _, t := range targets {
+        live[t] = true
+        if _, ok := src.streams[t]; ok {
+            continue
+        }
+        streamCtx, cancel := context.WithCancel(ctx)
+        src.streams[t] = cancel
+        go src.run(streamCtx, t)
+    }
+    for t, cancel := range src.streams {
+        if !live[t] {
+            cancel()
+            delete(src.streams, t)
+        }
+    }
+}
+
+// run keeps a stream open to an agent until ctx is done, reconnecting after
+// retryDelay.
+func (src *orcaSource) run(ctx context.Context, t agentTarget) {
+    logger := src.logger.WithValues("node", t.node, "address", t.address)
+    conn, err := grpc.NewClient(t.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        logger.Error(err, "Creating ORCA client")
+        return
+    }
+    defer conn.Close()
+    client := orcaservicepb.NewOpenRcaServiceClient(conn)
+
+    var delay time.Duration
+    for {
+        received, err := src.stream(ctx, client, t.node)
+        if ctx.Err() != nil {
+            return
+        }
+        delay = src.retryDelay(delay, received)
+        logger.V(2).Info("ORCA stream closed", "err", err, "retryAfter", delay)
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(delay):
+        }
+    }
+}
+
+// retryDelay is the delay before reconnecting to an agent whose stream
+// closed after the last delay: ReportInterval if the stream received a
+// report, else a delay doubling from ReportInterval up to MaxBackoff.
+func (src *orcaSource) retryDelay(last time.Duration, received bool) time.Duration {
+    if received || last == 0 {
+        return src.cfg.ReportInterval.Duration
+    }
+    return min(2*last, src.cfg.MaxBackoff.Duration)
+}
+
+// stream ingests the reports of one stream until it fails, returning
+// whether any report was received.
+func (src *orcaSource) stream(ctx context.Context, client orcaservicepb.OpenRcaServiceClient, node string) (bool, error) {
+    stream, err := client.StreamCoreMetrics(ctx, &orcaservicepb.OrcaLoadReportRequest{
+        ReportInterval: durationpb.New(src.cfg.ReportInterval.Duration),
+    })
+    if err != nil {
+        return false, err
+    }
+    received := false
+    for {
+        report, err := stream.Recv()
+        if err != nil {
+            return received, err
+        }
+        received = true
+        src.ingest(node, report)
+    }
+}
+
+func (src *orcaSource) ingest(node string, report *orcapb.OrcaLoadReport) {
+    signal, ok1 := src.cfg.Signal.value(report)
+    capacity, ok2 := src.cfg.Capacity.value(report)
+    overprovision, ok3 := src.cfg.Overprovision.value(report)
+    if !ok1 || !ok2 || !ok3 {
+        signalSamples.WithLabelValues(sampleInvalidValue).Inc()
+        return
+    }
+    m := &pb.Signal{Signal: signal, Capacity: capacity, Overprovision: overprovision}
+    if reason := validateSignal(node, m); reason != "" {
+        signalSamples.WithLabelValues(reason).Inc()
+        return
+    }
+    signalSamples.WithLabelValues(sampleAccepted).Inc()
+    src.state.Ingest(orcaSourceName, node, WithCapacity(capacity),
+        WithSignal(signal),
+        WithOverprovision(overprovision))
+}
+
+// value maps a load report onto a HostInfo field, returning false if the
+// report lacks the named utilization or metric.
+func (m OrcaMetric) value(report *orcapb.OrcaLoadReport) (float64, bool) {
+    var v float64
+    switch {
+    case m.Key == "":
+    case m.Key == orcaCPUUtilization:
+        v = report.GetCpuUtilization()
+    case m.Key == orcaMemUtilization:
+        v = report.GetMemUtilization()
+    case m.Key == orcaApplicationUtilization:
+        v = report.GetApplicationUtilization()
+    case strings.HasPrefix(m.Key, orcaUtilizationPrefix):
+        var ok bool
+        if v, ok = report.GetUtilization()[strings.TrimPrefix(m.Key, orcaUtilizationPrefix)]; !ok {
+            return 0, false
+        }
+    case strings.HasPrefix(m.Key, orcaNamedMetricPrefix):
+        var ok bool
+        if v, ok = report.GetNamedMetrics()[strings.TrimPrefix(m.Key, orcaNamedMetricPrefix)]; !ok {
+            return 0, false
+        }
+    default:
+        return 0, false
+    }
+    return m.Offset + m.Scale*v, true
+}
+
+// validOrcaKey reports whether an OrcaMetric key names a load report field.
+func validOrcaKey(key string) bool {
+    switch key {
+    case "", orcaCPUUtilization, orcaMemUtilization, orcaApplicationUtilization:
+        return true
+    }
+    for _, prefix := range []string{orcaUtilizationPrefix, orcaNamedMetricPrefix} {
+        if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,159 @@
+package plugin
+
+import (
+    "context"
+    "math"
+    "net"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+    orcaservicepb "github.com/cncf/xds/go/xds/service/orca/v3"
+    "github.com/go-logr/logr"
+    "google.golang.org/grpc"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testOrcaReport = &orcapb.OrcaLoadReport{
+    CpuUtilization:         0.5,
+    MemUtilization:         0.25,
+    ApplicationUtilization: 0.75,
+    Utilization:            map[string]float64{"gpu": 0.125},
+    NamedMetrics:           map[string]float64{"capacity": 4},
+}
+
+func TestOrcaMetricValue(t *testing.T) {
+    for _, tc := range []struct {
+        name   string
+        metric OrcaMetric
+        want   float64
+        ok     bool
+    }{
+        {name: "offset only", metric: OrcaMetric{Offset: 2, Scale: 3}, want: 2, ok: true},
+        {name: "cpu", metric: OrcaMetric{Key: orcaCPUUtilization, Scale: 1}, want: 0.5, ok: true},
+        {name: "memory", metric: OrcaMetric{Key: orcaMemUtilization, Scale: 2}, want: 0.5, ok: true},
+        {name: "application", metric: OrcaMetric{Key: orcaApplicationUtilization, Scale: -1, Offset: 1}, want: 0.25, ok: true},
+        {name: "named utilization", metric: OrcaMetric{Key: "utilization.gpu", Scale: 8}, want: 1, ok: true},
+        {name: "named metric", metric: OrcaMetric{Key: "named_metrics.capacity", Scale: 0.5, Offset: -1}, want: 1, ok: true},
+        {name: "missing utilization", metric: OrcaMetric{Key: "utilization.tpu", Scale: 1}},
+        {name: "missing metric", metric: OrcaMetric{Key: "named_metrics.pods", Scale: 1}},
+        {name: "unknown key", metric: OrcaMetric{Key: "rps_fractional", Scale: 1}},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            got, ok := tc.metric.value(testOrcaReport)
+            if ok != tc.ok || got != tc.want {
+                t.Errorf("value() = %v, %v, want %v, %v", got, ok, tc.want, tc.ok)
+            }
+        })
+    }
+}
+
+func TestValidOrcaKey(t *testing.T) {
+    for key, want := range map[string]bool{
+        "":                         true,
+        orcaCPUUtilization:         true,
+        orcaMemUtilization:         true,
+        orcaApplicationUtilization: true,
+        "utilization.gpu":          true,
+        "named_metrics.capacity":   true,
+        "utilization.":             false,
+        "named_metrics.":           false,
+        "rps_fractional":           false,
+        "cpu":                      false,
+    } {
+        if got := validOrcaKey(key); got != want {
+            t.Errorf("validOrcaKey(%q) = %v, want %v", key, got, want)
+        }
+    }
+}
+
+func TestOrcaIngest(t *testing.T) {
+    cfg := defaultProntoArgs().OrcaSource
+    for _, tc := range []struct {
+        name   string
+        report *orcapb.OrcaLoadReport
+        want   bool
+    }{
+        {name: "valid", report: testOrcaReport, want: true},
+        {name: "missing capacity", report: &orcapb.OrcaLoadReport{CpuUtilization: 0.5}},
+        {name: "non-finite", report: &orcapb.OrcaLoadReport{CpuUtilization: math.NaN(),
+            NamedMetrics: map[string]float64{"capacity": 4}}},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            src := &orcaSource{state: newTestState(), cfg: cfg, logger: logr.Discard()}
+            src.ingest("n", tc.report)
+            hi := src.state.GetHost("n")
+            if (hi != nil) != tc.want {
+                t.Fatalf("ingested = %v, want %v", hi != nil, tc.want)
+            }
+            if hi != nil && (hi.Signal != 0.5 || hi.Capacity != 4 || hi.Source != orcaSourceName) {
+                t.Errorf("ingested signal %v capacity %v from %q, want 0.5 and 4 from %q",
+                    hi.Signal, hi.Capacity, hi.Source, orcaSourceName)
+            }
+        })
+    }
+}
+
+func TestOrcaRetryDelay(t *testing.T) {
+    src := &orcaSource{cfg: OrcaSource{
+        ReportInterval: metav1.Duration{Duration: time.Second},
+        MaxBackoff:     metav1.Duration{Duration: 5 * time.Second},
+    }}
+    var delay time.Duration
+    for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+        if delay = src.retryDelay(delay, false); delay != want {
+            t.Errorf("failure %d: delay = %v, want %v", i+1, delay, want)
+        }
+    }
+    if got := src.retryDelay(delay, true); got != time.Second {
+        t.Errorf("delay after a stream with reports = %v, want %v", got, time.Second)
+    }
+}
+
+// testOrcaBackend sends one report on each stream and closes it.
+type testOrcaBackend struct {
+    orcaservicepb.UnimplementedOpenRcaServiceServer
+    streams atomic.Int32
+}
+
+func (b *testOrcaBackend) StreamCoreMetrics(_ *orcaservicepb.OrcaLoadReportRequest, stream orcaservicepb.OpenRcaService_StreamCoreMetricsServer) error {
+    b.streams.Add(1)
+    return stream.Send(testOrcaReport)
+}
+
+func TestOrcaReconnects(t *testing.T) {
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    backend := &testOrcaBackend{}
+    s := grpc.NewServer()
+    orcaservicepb.RegisterOpenRcaServiceServer(s, backend)
+    go s.Serve(lis)
+    defer s.Stop()
+
+    cfg := defaultProntoArgs().OrcaSource
+    cfg.ReportInterval = metav1.Duration{Duration: 10 * time.Millisecond}
+    cfg.MaxBackoff = metav1.Duration{Duration: 10 * time.Millisecond}
+    src := &orcaSource{state: newTestState(), cfg: cfg, logger: logr.Discard()}
+
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan struct{})
+    go func() {
+        src.run(ctx, agentTarget{node: "n", address: lis.Addr().String()})
+        close(done)
+    }()
+    deadline := time.Now().Add(5 * time.Second)
+    for backend.streams.Load() < 3 {
+        if time.Now().After(deadline) {
+            t.Fatalf("opened %d streams, want the source to keep reconnecting", backend.streams.Load())
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    cancel()
+    <-done
+    if hi := src.state.GetHost("n"); hi == nil || hi.Capacity != 4 {
+        t.Errorf("host = %+v, want the backend's report ingested", hi)
+    }
+}
@@ -3,14 +3,15 @@ package plugin
 import (
 	"context"
 	"fmt"
-	"math"
-	//"strconv"
+	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	framework "k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/clock"
 )
 
 const Name = "Pronto"
@@ -21,6 +22,26 @@ type HostInfo struct {
 	Signal          float64
 	Capacity        float64
 	Overprovision   float64
+
+    // CapacityMean and CapacityVar are exponentially weighted estimates of
+    // the mean and variance of Capacity across the node's signal samples.
+    CapacityMean    float64
+    CapacityVar     float64
+    Samples         int
+    LastUpdate      time.Time
+
+    // PlacedSinceSignal counts the pods reserved on the node since its last
+    // signal, and PlacedLastInterval those reserved between its last two.
+    PlacedSinceSignal  int
+    PlacedLastInterval int
+
+    // SampleSpan is the span that ingested the node's last signal.
+    SampleSpan      trace.SpanContext
+    // Source is the signal source that produced the node's last signal.
+    Source          string
+    // LowConfidence marks a last signal that was derived coarsely, e.g.
+    // from the Metrics API, rather than reported by a Pronto agent.
+    LowConfidence   bool
 }
 
 type BooleanState struct {
@@ -37,21 +58,98 @@ func (b *BooleanState) Clone() framework.StateData {
 type ProntoPlugin struct {
     logger klog.Logger
     handle      framework.Handle
+    args        *ProntoArgs
+    scorer      scorer
+    normalizer  normalizer
+    rng         *lockedRand
+    // profileName is the scheduler name of the profile this plugin belongs
+    // to. Pods for any other scheduler are only evaluated in shadow mode.
+    profileName string
+    shadow      *shadowState
+    audit       *auditLog
 
     prontoState
 }
 
+var _ framework.PreFilterPlugin = &ProntoPlugin{}
 var _ framework.FilterPlugin = &ProntoPlugin{}
+var _ framework.PostFilterPlugin = &ProntoPlugin{}
+var _ framework.PreScorePlugin = &ProntoPlugin{}
 var _ framework.ScorePlugin = &ProntoPlugin{}
 var _ framework.ReservePlugin = &ProntoPlugin{}
+var _ framework.PostBindPlugin = &ProntoPlugin{}
 
 // New initializes a new plugin and returns it.
 func New(ctx context.Context, obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+    return newPlugin(ctx, obj, handle, clock.RealClock{})
+}
+
+// NewWithClock returns a factory like New whose plugins read the time from
+// c, so that a simulation can run them on simulated time.
+func NewWithClock(c clock.PassiveClock) func(context.Context, runtime.Object, framework.Handle) (framework.Plugin, error) {
+    return func(ctx context.Context, obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+        return newPlugin(ctx, obj, handle, c)
+    }
+}
+
+func newPlugin(ctx context.Context, obj runtime.Object, handle framework.Handle, c clock.PassiveClock) (framework.Plugin, error) {
 	logger := klog.FromContext(ctx).WithValues("plugin", Name)
-	pl := &ProntoPlugin{logger: logger, handle: handle}
+    args, err := decodeProntoArgs(obj)
+    if err != nil {
+        return nil, err
+    }
+    rng := newLockedRand(args.Seed)
+    sc, err := newScorer(args.ScoringStrategy, rng)
+    if err != nil {
+        return nil, err
+    }
+    norm, err := newNormalizer(args.ScoreNormalizer)
+    if err != nil {
+        return nil, err
+    }
+
+	pl := &ProntoPlugin{logger: logger, handle: handle, args: args, scorer: sc,
+        normalizer: norm, rng: rng}
+    pl.smoothing = args.SignalSmoothing
+    pl.clock = c
+    tp, err := newTracerProvider(ctx, args)
+    if err != nil {
+        return nil, fmt.Errorf("creating tracer provider: %w", err)
+    }
+    pl.tracer = tp.Tracer(instrumentationName)
+    go func() {
+        <-ctx.Done()
+        tp.Shutdown(context.Background())
+    }()
+    if fwk, ok := handle.(interface{ ProfileName() string }); ok {
+        pl.profileName = fwk.ProfileName()
+    }
+    if args.Shadow.Enabled {
+        pl.shadow = newShadowState()
+        // Shadow evaluation lists nodes outside of a scheduling cycle.
+        handle.SharedInformerFactory().Core().V1().Nodes().Informer()
+    }
+    if args.Audit.Path != "" {
+        pl.audit = newAuditLog(args.Audit)
+        go func() {
+            <-ctx.Done()
+            pl.audit.Close()
+        }()
+    }
+    RegisterMetrics()
+    hostMetrics.track(&pl.prontoState)
+    go func() {
+        <-ctx.Done()
+        hostMetrics.untrack(&pl.prontoState)
+    }()
 	pl.HostReservations = make(map[string]*HostInfo)
-	pl.PodReserved = make(map[string]struct{})
-	pl.PodOverReserved = make(map[string]struct{})
+	pl.PodReserved = make(map[string]Reservation)
+	pl.PodOverReserved = make(map[string]Reservation)
+    pl.Agents = make(map[string]*AgentInfo)
+    pl.sourceSamples = make(map[string]map[string]time.Time)
+    for _, src := range args.Sources {
+        pl.precedence = append(pl.precedence, sourcePrecedence{name: src.Name, maxAge: src.MaxAge.Duration})
+    }
 
 	podInformer := handle.SharedInformerFactory().Core().V1().Pods()
 	podInformer.Informer().AddEventHandler(
@@ -61,7 +159,17 @@ func New(ctx context.Context, obj runtime.Object, handle framework.Handle) (fram
 		},
 	)
 
-    pl.prontoState.startPlacementServer(ctx, logger)
+    if err := pl.startSignalSources(ctx, logger); err != nil {
+        return nil, err
+    }
+    if args.Publish.Interval.Duration > 0 {
+        pl.startNodePublisher(ctx, handle.ClientSet(), args.Publish, logger)
+    }
+    if args.Debug.Address != "" {
+        if err := pl.startDebugServer(ctx, logger); err != nil {
+            return nil, err
+        }
+    }
 
 	return pl, nil
 }
@@ -70,6 +178,23 @@ func New(ctx context.Context, obj runtime.Object, handle framework.Handle) (fram
 // Name returns the plugin name.
 func (pl *ProntoPlugin) Name() string { return Name }
 
+// PreFilter starts the span that parents the spans of every other Pronto
+// extension point in the scheduling cycle.
+func (pl *ProntoPlugin) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
+    _, span := pl.tracer.Start(ctx, Name+"/SchedulingCycle",
+        trace.WithAttributes(podKey.String(klog.KObj(pod).String())))
+    defer span.End()
+
+    state.Write(cycleTraceKey, &cycleTrace{spanContext: span.SpanContext()})
+    state.Write(diagnosisStateKey, newCycleDiagnosis())
+    pl.startAudit(state, pod)
+    return nil, nil
+}
+
+func (pl *ProntoPlugin) PreFilterExtensions() framework.PreFilterExtensions {
+    return nil
+}
+
 func (pl *ProntoPlugin) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
     logger := klog.FromContext(klog.NewContext(ctx, pl.logger)).WithValues("ExtensionPoint", "Filter")
 	node := nodeInfo.Node()
@@ -77,10 +202,34 @@ func (pl *ProntoPlugin) Filter(ctx context.Context, state *framework.CycleState,
 		return framework.NewStatus(framework.Error, "node not found")
 	}
 
-    hostInfo := pl.GetHost(node.Name)
+    _, span := pl.startSpan(ctx, state, "Filter", trace.WithAttributes(nodeKey.String(node.Name)))
+    defer span.End()
+
+    audit := cycleAuditFrom(state)
+    diagnosis := cycleDiagnosisFrom(state)
+    reject := func(hostInfo *HostInfo, verdict, reason string) *framework.Status {
+        span.SetAttributes(filterVerdictKey.String(verdict))
+        filterRejections.WithLabelValues(verdict).Inc()
+        if audit != nil {
+            audit.filtered(node.Name, hostInfo, verdict, reason)
+        }
+        if diagnosis != nil {
+            diagnosis.rejected(node.Name, hostInfo, verdict)
+        }
+        return framework.NewStatus(framework.Unschedulable, filterReasons[verdict])
+    }
+
+    hostInfo := pl.judgedHost(node.Name)
     if hostInfo == nil {
-        return framework.NewStatus(framework.Unschedulable,
-            fmt.Sprintf("Node %v does not exist", node.Name))
+        return reject(nil, rejectNoData, fmt.Sprintf("Node %v does not exist", node.Name))
+    }
+    span.SetAttributes(hostAttributes(node.Name, hostInfo)...)
+    for _, link := range sampleLinks(hostInfo) {
+        span.AddLink(link)
+    }
+    now := pl.clock.Now()
+    if !hostInfo.LastUpdate.IsZero() {
+        signalAge.Observe(now.Sub(hostInfo.LastUpdate).Seconds())
     }
 
     needed := 1e-3
@@ -90,7 +239,15 @@ func (pl *ProntoPlugin) Filter(ctx context.Context, state *framework.CycleState,
             "needed", needed)
     }
 
-    if hostInfo.Capacity - float64(hostInfo.Reserved) > 1 {
+    switch pl.filterVerdict(hostInfo, now) {
+    case rejectStale:
+        return reject(hostInfo, rejectStale,
+            fmt.Sprintf("Node %v signal is older than %v", node.Name, pl.args.MaxSignalAge.Duration))
+    case verdictFits:
+        span.SetAttributes(filterVerdictKey.String(verdictFits))
+        if audit != nil {
+            audit.filtered(node.Name, hostInfo, verdictFits, "")
+        }
         state.Write(framework.StateKey(node.Name), &BooleanState{val: false})
         return framework.NewStatus(framework.Success, "")
     }
@@ -100,13 +257,51 @@ func (pl *ProntoPlugin) Filter(ctx context.Context, state *framework.CycleState,
         //return framework.NewStatus(framework.Success, "")
     //}
 
-    return framework.NewStatus(framework.Unschedulable,
+    return reject(hostInfo, rejectNoCapacity,
         fmt.Sprintf("Node %v does not meet signal requirements: capacity: %f reserved: %d", node.Name, hostInfo.Capacity, hostInfo.Reserved))
 }
 
-// Score reads the node signal, predicts via Kalman, subtracts reserved, and returns a score.
+// filterVerdict is Filter's decision for a node: verdictFits or the reason
+// it is rejected. Shadow evaluation shares it so it decides as Filter would.
+func (pl *ProntoPlugin) filterVerdict(hostInfo *HostInfo, now time.Time) string {
+    if hostInfo == nil {
+        return rejectNoData
+    }
+    if maxAge := pl.args.MaxSignalAge.Duration; maxAge > 0 && now.Sub(hostInfo.LastUpdate) > maxAge {
+        return rejectStale
+    }
+    if !fits(hostInfo) {
+        return rejectNoCapacity
+    }
+    return verdictFits
+}
+
+// PostFilter explains why no node could take the pod. The summary is emitted
+// as an event on the pod and returned as the status message, which the
+// scheduler appends to the pod's PodScheduled condition. PostFilter also
+// records the pod's audit log entry. It never makes the pod schedulable.
+func (pl *ProntoPlugin) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, filteredNodeStatusMap framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+    pl.finishAudit(state, "", auditUnschedulable)
+
+    diagnosis := cycleDiagnosisFrom(state)
+    if diagnosis == nil {
+        return nil, framework.NewStatus(framework.Unschedulable)
+    }
+    msg := diagnosis.summary()
+    if msg == "" {
+        return nil, framework.NewStatus(framework.Unschedulable)
+    }
+    pl.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeWarning, "ProntoUnschedulable", "Scheduling", msg)
+    return nil, framework.NewStatus(framework.Unschedulable, msg)
+}
+
+// Score ranks the node using the configured scoring strategy.
 func (pl *ProntoPlugin) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string,) (int64, *framework.Status) {
     logger := klog.FromContext(klog.NewContext(ctx, pl.logger)).WithValues("ExtensionPoint", "Score")
+    if !isSampled(state, nodeName) {
+        return 0, nil
+    }
+
     nodeInfo, err := pl.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
     if err != nil {
         return 0, framework.NewStatus(framework.Error, fmt.Sprintf("getting node %q from Snapshot: %v", nodeName, err))
@@ -117,70 +312,66 @@ func (pl *ProntoPlugin) Score(ctx context.Context, state *framework.CycleState,
 		return 0, framework.NewStatus(framework.Error, "node not found")
 	}
 
-    hostInfo := pl.GetHost(node.Name)
-    score := signalScorer(hostInfo.Capacity)
+    hostInfo := pl.judgedHost(node.Name)
+    if hostInfo == nil {
+        return 0, framework.NewStatus(framework.Error,
+            fmt.Sprintf("Node %v does not exist", node.Name))
+    }
+    score := int64(pl.scorer(hostInfo, pl.clock.Now()) * scoreScale)
+
+    _, span := pl.startSpan(ctx, state, "Score",
+        trace.WithAttributes(hostAttributes(node.Name, hostInfo)...),
+        trace.WithAttributes(scoreKey.Int64(score)),
+        trace.WithLinks(sampleLinks(hostInfo)...))
+    span.End()
+    if audit := cycleAuditFrom(state); audit != nil {
+        audit.scored(node.Name, score)
+    }
 
     if logger.V(10).Enabled() {
         logger.Info("Pronto Signal", "podName", pod.Name, "nodeName", node.Name, "scorer", Name,
-            "signal", hostInfo.Signal, "score", score)
+            "strategy", pl.args.ScoringStrategy.Type, "signal", hostInfo.Signal, "score", score)
     }
 
-    return int64(score), nil
+    return score, nil
 }
 
-func signalScorer(signal float64) int64 {
-    return int64(signal * 100)
-}
-
-// extractSignalFromNode reads a numeric signal from a Node label.
-//func extractSignalFromNode(node *v1.Node) float64 {
-    //if val, ok := node.Annotations["pronto/signal"]; ok {
-        //if f, err := strconv.ParseFloat(val, 64); err == nil {
-            //return f
-        //}
-    //}
-    //return 0
-//}
-//
-// extractSignalFromNode reads a numeric signal from a Node label.
-//func extractCapacityFromNode(node *v1.Node) float64 {
-    //if val, ok := node.Annotations["pronto/pod-cost"]; ok {
-        //if f, err := strconv.ParseFloat(val, 64); err == nil {
-            //return f
-        //}
-    //}
-    //return 0.1
-//}
-//
 func (pl *ProntoPlugin) ScoreExtensions() framework.ScoreExtensions {
     return pl
 }
 
 // NormalizeScore invoked after scoring all nodes.
+// Nodes left out by PreScore sampling are excluded from normalization and
+// given the minimum score. Sampled nodes are normalized into
+// (MinNodeScore, MaxNodeScore], so they beat the unsampled ones even when
+// the normalizer gives them all MinNodeScore, e.g. for k = 1.
 func (pl *ProntoPlugin) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) *framework.Status {
-	// Find highest and lowest scores.
-	var highest int64 = -math.MaxInt64
-	var lowest int64 = math.MaxInt64
-	for _, nodeScore := range scores {
-		if nodeScore.Score > highest {
-			highest = nodeScore.Score
-		}
-		if nodeScore.Score < lowest {
-			lowest = nodeScore.Score
-		}
-	}
-
-	// Transform the highest to lowest score range to fit the framework's min to max node score range.
-	oldRange := highest - lowest
-	newRange := framework.MaxNodeScore - framework.MinNodeScore
-	for i, nodeScore := range scores {
-		if oldRange == 0 {
-			scores[i].Score = framework.MinNodeScore
-		} else {
-			scores[i].Score = ((nodeScore.Score - lowest) * newRange / oldRange) + framework.MinNodeScore
-		}
-	}
+    if _, err := state.Read(sampleStateKey); err != nil {
+        pl.normalizer(scores)
+        if audit := cycleAuditFrom(state); audit != nil {
+            audit.normalized(scores)
+        }
+        return nil
+    }
 
+    var sampled framework.NodeScoreList
+    var indices []int
+    for i, nodeScore := range scores {
+        if isSampled(state, nodeScore.Name) {
+            sampled = append(sampled, nodeScore)
+            indices = append(indices, i)
+        } else {
+            scores[i].Score = framework.MinNodeScore
+        }
+    }
+    pl.normalizer(sampled)
+    for j, i := range indices {
+        scores[i].Score = framework.MinNodeScore + 1 +
+            (sampled[j].Score-framework.MinNodeScore)*(scoreRange-1)/scoreRange
+    }
+    if audit := cycleAuditFrom(state); audit != nil {
+        audit.normalized(scores)
+    }
 	return nil
 }
 
@@ -212,6 +403,16 @@ func (pl *ProntoPlugin) Reserve(
         logger.Info("Pronto Signal", "Node Name", node.Name, "OverSaturated", oversat.(*BooleanState).val)
     }
 
+    _, span := pl.startSpan(ctx, state, "Reserve", trace.WithAttributes(
+        podKey.String(klog.KObj(pod).String()), nodeKey.String(nodeName)))
+    defer span.End()
+    if hostInfo := pl.GetHost(nodeName); hostInfo != nil {
+        span.SetAttributes(hostAttributes(nodeName, hostInfo)...)
+        for _, link := range sampleLinks(hostInfo) {
+            span.AddLink(link)
+        }
+    }
+
     //pl.ReservePod(pod.Name, nodeName, oversat.(*BooleanState).val)
     pl.ReservePod(pod.Name, nodeName, false)
 
@@ -225,8 +426,24 @@ func (pl *ProntoPlugin) Unreserve(
     pod *v1.Pod,
     nodeName string,
 ) {
-    pl.UnReservePod(pod.Name, nodeName)
-    pl.UnOverReservePod(pod.Name, nodeName)
+    _, span := pl.startSpan(ctx, state, "Unreserve", trace.WithAttributes(
+        podKey.String(klog.KObj(pod).String()), nodeKey.String(nodeName)))
+    defer span.End()
+
+    pl.UnReservePod(pod.Name, nodeName, reservationUnreserved)
+    pl.UnOverReservePod(pod.Name, nodeName, reservationUnreserved)
+    pl.finishAudit(state, nodeName, auditFailed)
+}
+
+// PostBind writes the audit record once the pod is bound; a Permit or Bind
+// failure reaches Unreserve instead and is recorded as failed.
+func (pl *ProntoPlugin) PostBind(
+    ctx context.Context,
+    state *framework.CycleState,
+    pod *v1.Pod,
+    nodeName string,
+) {
+    pl.finishAudit(state, nodeName, auditScheduled)
 }
 
 func (pl *ProntoPlugin) onPodUpdate(oldObj, newObj interface{}) {
@@ -239,9 +456,19 @@ func (pl *ProntoPlugin) onPodUpdate(oldObj, newObj interface{}) {
     // Only consider pods that have a node assigned
     nodeName := newPod.Spec.NodeName
 
+    if pl.isShadowed(newPod) {
+        if oldPod.Spec.NodeName == "" && nodeName != "" {
+            pl.shadowEvaluate(newPod, nodeName)
+        }
+        if !newPending && nodeName != "" {
+            pl.shadow.unreserve(newPod.Name)
+        }
+        return
+    }
+
     // Entered Running
     if !newPending && nodeName != "" {
-        pl.UnReservePod(oldPod.Name, nodeName)
+        pl.UnReservePod(oldPod.Name, nodeName, reservationStarted)
     }
 }
 func (pl *ProntoPlugin) onPodDelete(obj interface{}) {
@@ -249,6 +476,11 @@ func (pl *ProntoPlugin) onPodDelete(obj interface{}) {
     // Only consider pods that have a node assigned
     nodeName := pod.Spec.NodeName
 
-    pl.UnReservePod(pod.Name, nodeName)
-    pl.UnOverReservePod(pod.Name, nodeName)
+    if pl.isShadowed(pod) {
+        pl.shadow.unreserve(pod.Name)
+        return
+    }
+
+    pl.UnReservePod(pod.Name, nodeName, reservationDeleted)
+    pl.UnOverReservePod(pod.Name, nodeName, reservationDeleted)
 }
@@ -0,0 +1,125 @@
+package plugin
+
+import (
+    "context"
+    "encoding/json"
+    "math"
+    "strconv"
+    "time"
+
+    "github.com/go-logr/logr"
+    "golang.org/x/time/rate"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/apimachinery/pkg/util/wait"
+    "k8s.io/client-go/kubernetes"
+)
+
+// Node annotations written by the publisher. They are distinct from the
+// pronto/signal annotation agents may write, so the scheduler never reads
+// back its own view.
+const (
+    observedSignalAnnotation   = "pronto/observed-signal"
+    observedCapacityAnnotation = "pronto/observed-capacity"
+    capacityEstimateAnnotation = "pronto/capacity-estimate"
+    reservedAnnotation         = "pronto/reserved"
+    observedAtAnnotation       = "pronto/observed-at"
+)
+
+// publishedView is the part of a node's HostInfo written to its annotations.
+type publishedView struct {
+    signal           float64
+    capacity         float64
+    capacityEstimate float64
+    reserved         int
+}
+
+func newPublishedView(hi *HostInfo) publishedView {
+    return publishedView{
+        signal:           hi.Signal,
+        capacity:         hi.Capacity,
+        capacityEstimate: hi.CapacityMean,
+        reserved:         hi.Reserved,
+    }
+}
+
+// changed reports whether v differs from old by more than the relative
+// threshold in any field.
+func (v publishedView) changed(old publishedView, threshold float64) bool {
+    differs := func(a, b float64) bool {
+        return math.Abs(a-b) > threshold*math.Max(math.Abs(b), 1)
+    }
+    return v.reserved != old.reserved ||
+        differs(v.signal, old.signal) ||
+        differs(v.capacity, old.capacity) ||
+        differs(v.capacityEstimate, old.capacityEstimate)
+}
+
+// nodePublisher periodically writes Pronto's view of each node to the node's
+// annotations, so dashboards and kubectl can see what the scheduler sees.
+type nodePublisher struct {
+    state     *prontoState
+    client    kubernetes.Interface
+    limiter   *rate.Limiter
+    threshold float64
+    published map[string]publishedView
+    logger    logr.Logger
+}
+
+func (ps *prontoState) startNodePublisher(ctx context.Context, client kubernetes.Interface, cfg Publish, logger logr.Logger) {
+    np := &nodePublisher{
+        state:     ps,
+        client:    client,
+        limiter:   rate.NewLimiter(rate.Limit(cfg.QPS), cfg.Burst),
+        threshold: cfg.MinChange,
+        published: make(map[string]publishedView),
+        logger:    logger.WithValues("controller", "node-publisher"),
+    }
+    go wait.UntilWithContext(ctx, np.publish, cfg.Interval.Duration)
+}
+
+func (np *nodePublisher) publish(ctx context.Context) {
+    hosts := np.state.hosts()
+    for name := range np.published {
+        if _, ok := hosts[name]; !ok {
+            delete(np.published, name)
+        }
+    }
+
+    for name, hostInfo := range hosts {
+        view := newPublishedView(&hostInfo)
+        if old, ok := np.published[name]; ok && !view.changed(old, np.threshold) {
+            continue
+        }
+        if err := np.limiter.Wait(ctx); err != nil {
+            return
+        }
+        if err := np.patch(ctx, name, view, hostInfo.LastUpdate); err != nil {
+            if !apierrors.IsNotFound(err) {
+                np.logger.Error(err, "Publishing node view", "node", name)
+            }
+            continue
+        }
+        np.published[name] = view
+    }
+}
+
+func (np *nodePublisher) patch(ctx context.Context, name string, view publishedView, observedAt time.Time) error {
+    patch, err := json.Marshal(map[string]interface{}{
+        "metadata": map[string]interface{}{
+            "annotations": map[string]string{
+                observedSignalAnnotation:   strconv.FormatFloat(view.signal, 'f', -1, 64),
+                observedCapacityAnnotation: strconv.FormatFloat(view.capacity, 'f', -1, 64),
+                capacityEstimateAnnotation: strconv.FormatFloat(view.capacityEstimate, 'f', -1, 64),
+                reservedAnnotation:         strconv.Itoa(view.reserved),
+                observedAtAnnotation:       observedAt.UTC().Format(time.RFC3339),
+            },
+        },
+    })
+    if err != nil {
+        return err
+    }
+    _, err = np.client.CoreV1().Nodes().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+    return err
+}
@@ -0,0 +1,153 @@
+package plugin
+
+import (
+    "context"
+    "encoding/json"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/go-logr/logr"
+    "golang.org/x/time/rate"
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/client-go/kubernetes/fake"
+    k8stesting "k8s.io/client-go/testing"
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestPublishedViewChanged(t *testing.T) {
+    old := publishedView{signal: 0.5, capacity: 10, capacityEstimate: 8, reserved: 2}
+    tests := []struct {
+        name      string
+        view      publishedView
+        threshold float64
+        want      bool
+    }{
+        {"same view", old, 0.1, false},
+        {"capacity within threshold", publishedView{signal: 0.5, capacity: 10.9, capacityEstimate: 8, reserved: 2}, 0.1, false},
+        {"capacity past threshold", publishedView{signal: 0.5, capacity: 11.1, capacityEstimate: 8, reserved: 2}, 0.1, true},
+        {"estimate past threshold", publishedView{signal: 0.5, capacity: 10, capacityEstimate: 7, reserved: 2}, 0.1, true},
+        // Values below 1 are compared against an absolute threshold, so a
+        // small signal does not republish on every wobble.
+        {"small signal within threshold", publishedView{signal: 0.59, capacity: 10, capacityEstimate: 8, reserved: 2}, 0.1, false},
+        {"small signal past threshold", publishedView{signal: 0.61, capacity: 10, capacityEstimate: 8, reserved: 2}, 0.1, true},
+        {"any reservation change", publishedView{signal: 0.5, capacity: 10, capacityEstimate: 8, reserved: 3}, 0.1, true},
+        {"zero threshold", publishedView{signal: 0.5, capacity: 10.001, capacityEstimate: 8, reserved: 2}, 0, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.view.changed(old, tt.threshold); got != tt.want {
+                t.Errorf("changed = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+// patches returns the node merge patches the client has received, by node.
+func patches(t *testing.T, client *fake.Clientset) map[string]map[string]string {
+    t.Helper()
+    got := map[string]map[string]string{}
+    for _, action := range client.Actions() {
+        patch, ok := action.(k8stesting.PatchAction)
+        if !ok || patch.GetResource().Resource != "nodes" {
+            continue
+        }
+        if patch.GetPatchType() != types.MergePatchType {
+            t.Errorf("patch type = %v, want %v", patch.GetPatchType(), types.MergePatchType)
+        }
+        var body struct {
+            Metadata struct {
+                Annotations map[string]string `json:"annotations"`
+            } `json:"metadata"`
+        }
+        if err := json.Unmarshal(patch.GetPatch(), &body); err != nil {
+            t.Fatal(err)
+        }
+        got[patch.GetName()] = body.Metadata.Annotations
+    }
+    client.ClearActions()
+    return got
+}
+
+func TestNodePublisher(t *testing.T) {
+    ctx := context.Background()
+    observedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+    ps := newTestState()
+    ps.clock = clocktesting.NewFakePassiveClock(observedAt)
+    ps.smoothing = 1
+    ps.UpdateHostInfo("a", WithSignal(0.25), WithCapacity(10))
+    ps.UpdateHostInfo("gone", WithCapacity(1))
+
+    client := fake.NewSimpleClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+    np := &nodePublisher{
+        state:     ps,
+        client:    client,
+        limiter:   rate.NewLimiter(rate.Inf, 1),
+        threshold: 0.1,
+        published: make(map[string]publishedView),
+        logger:    logr.Discard(),
+    }
+
+    np.publish(ctx)
+    want := map[string]map[string]string{
+        "a": {
+            observedSignalAnnotation:   "0.25",
+            observedCapacityAnnotation: "10",
+            capacityEstimateAnnotation: "10",
+            reservedAnnotation:         "0",
+            observedAtAnnotation:       "2024-01-01T12:00:00Z",
+        },
+        // The node is not in the API server; the patch fails and is retried.
+        "gone": {
+            observedSignalAnnotation:   "0",
+            observedCapacityAnnotation: "1",
+            capacityEstimateAnnotation: "1",
+            reservedAnnotation:         "0",
+            observedAtAnnotation:       "2024-01-01T12:00:00Z",
+        },
+    }
+    if got := patches(t, client); !reflect.DeepEqual(got, want) {
+        t.Errorf("first publish patched %v, want %v", got, want)
+    }
+    node, err := client.CoreV1().Nodes().Get(ctx, "a", metav1.GetOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(node.Annotations, want["a"]) {
+        t.Errorf("node annotations = %v, want %v", node.Annotations, want["a"])
+    }
+    if _, ok := np.published["gone"]; ok {
+        t.Error("failed patch recorded as published")
+    }
+
+    // A change within MinChange is not published.
+    ps.UpdateHostInfo("a", WithSignal(0.25), WithCapacity(10.5))
+    ps.mu.Lock()
+    delete(ps.HostReservations, "gone")
+    ps.mu.Unlock()
+    np.publish(ctx)
+    if got := patches(t, client); len(got) != 0 {
+        t.Errorf("change within threshold patched %v", got)
+    }
+
+    // A new reservation always is.
+    ps.mu.Lock()
+    ps.HostReservations["a"].Reserved++
+    ps.mu.Unlock()
+    np.publish(ctx)
+    got := patches(t, client)
+    if got["a"][reservedAnnotation] != "1" || got["a"][observedCapacityAnnotation] != "10.5" || len(got) != 1 {
+        t.Errorf("reservation change patched %v", got)
+    }
+
+    // Nodes that have left the state are forgotten.
+    ps.mu.Lock()
+    delete(ps.HostReservations, "a")
+    ps.mu.Unlock()
+    np.publish(ctx)
+    if len(np.published) != 0 {
+        t.Errorf("published views = %v, want none", np.published)
+    }
+}
@@ -0,0 +1,300 @@
+package plugin
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "github.com/go-logr/logr"
+    "github.com/golang/snappy"
+    "google.golang.org/protobuf/encoding/protowire"
+)
+
+const remoteWriteSourceName = "remoteWrite"
+
+// metricNameLabel is the label holding a Prometheus series' metric name.
+const metricNameLabel = "__name__"
+
+// maxRemoteWriteBody bounds the compressed remote-write request body, and
+// maxRemoteWriteDecoded the body once decompressed.
+const (
+    maxRemoteWriteBody    = 32 << 20
+    maxRemoteWriteDecoded = 128 << 20
+)
+
+// remoteWriteSource accepts Prometheus remote-write requests and derives each
+// node's signal from its series with the configured expressions.
+type remoteWriteSource struct {
+    state  *prontoState
+    cfg    RemoteWriteSource
+    logger logr.Logger
+
+    signal, capacity, overprovision seriesExpr
+    // names are the metric names the expressions read; other series are
+    // dropped on arrival.
+    names map[string]bool
+
+    mu    sync.Mutex
+    nodes map[string]nodeSeries
+}
+
+func newRemoteWriteSource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    src := &remoteWriteSource{
+        state:  &pl.prontoState,
+        cfg:    pl.args.RemoteWriteSource,
+        logger: logger,
+        names:  make(map[string]bool),
+        nodes:  make(map[string]nodeSeries),
+    }
+    var err error
+    if src.signal, err = parseSeriesExpr(src.cfg.Signal); err != nil {
+        return nil, fmt.Errorf("remoteWriteSource.signal: %w", err)
+    }
+    if src.capacity, err = parseSeriesExpr(src.cfg.Capacity); err != nil {
+        return nil, fmt.Errorf("remoteWriteSource.capacity: %w", err)
+    }
+    src.overprovision = numberExpr(0)
+    if src.cfg.Overprovision != "" {
+        if src.overprovision, err = parseSeriesExpr(src.cfg.Overprovision); err != nil {
+            return nil, fmt.Errorf("remoteWriteSource.overprovision: %w", err)
+        }
+    }
+    for _, e := range []seriesExpr{src.signal, src.capacity, src.overprovision} {
+        e.metrics(src.names)
+    }
+    return src, nil
+}
+
+func (src *remoteWriteSource) Name() string { return remoteWriteSourceName }
+
+func (src *remoteWriteSource) Start(ctx context.Context) error {
+    mux := http.NewServeMux()
+    mux.HandleFunc(src.cfg.Path, src.serveWrite)
+    srv := &http.Server{Addr: src.cfg.Address, Handler: mux}
+    go func() {
+        <-ctx.Done()
+        srv.Close()
+    }()
+    go func() {
+        src.logger.Info("Remote-write endpoint listening", "address", src.cfg.Address, "path", src.cfg.Path)
+        if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            src.logger.Error(err, "Remote-write endpoint failed")
+        }
+    }()
+    return nil
+}
+
+func (src *remoteWriteSource) serveWrite(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    compressed, err := io.ReadAll(io.LimitReader(r.Body, maxRemoteWriteBody))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    // A small body can claim a huge decoded length; check it before
+    // snappy allocates the buffer.
+    n, err := snappy.DecodedLen(compressed)
+    if err != nil {
+        http.Error(w, fmt.Sprintf("decompressing request: %v", err), http.StatusBadRequest)
+        return
+    }
+    if n > maxRemoteWriteDecoded {
+        http.Error(w, fmt.Sprintf("decompressed request is %d bytes, over the %d byte limit", n, maxRemoteWriteDecoded), http.StatusRequestEntityTooLarge)
+        return
+    }
+    body, err := snappy.Decode(nil, compressed)
+    if err != nil {
+        http.Error(w, fmt.Sprintf("decompressing request: %v", err), http.StatusBadRequest)
+        return
+    }
+    if err := src.write(body, time.Now()); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// write applies a WriteRequest and ingests the signal of every node it
+// touched whose expressions all have a value.
+func (src *remoteWriteSource) write(body []byte, now time.Time) error {
+    src.mu.Lock()
+    defer src.mu.Unlock()
+
+    touched := map[string]bool{}
+    err := decodeWriteRequest(body, func(labels map[string]string, samples []seriesSample) {
+        node := labels[src.cfg.NodeLabel]
+        if node == "" || !src.names[labels[metricNameLabel]] || len(samples) == 0 {
+            return
+        }
+        ns, ok := src.nodes[node]
+        if !ok {
+            ns = make(nodeSeries)
+            src.nodes[node] = ns
+        }
+        key := seriesKey(labels)
+        s, ok := ns[key]
+        if !ok {
+            s = &series{labels: labels}
+            ns[key] = s
+        }
+        for _, sample := range samples {
+            if s.seen {
+                if sample.ts <= s.last.ts {
+                    continue
+                }
+                s.prev, s.hasPrev = s.last, true
+            }
+            s.last, s.seen = sample, true
+        }
+        touched[node] = true
+    })
+    if err != nil {
+        return err
+    }
+
+    for node := range touched {
+        ns := src.nodes[node]
+        src.prune(ns, now)
+        signal, ok1 := src.signal.eval(ns)
+        capacity, ok2 := src.capacity.eval(ns)
+        overprovision, ok3 := src.overprovision.eval(ns)
+        if !ok1 || !ok2 || !ok3 {
+            continue
+        }
+        m := &pb.Signal{Signal: signal, Capacity: capacity, Overprovision: overprovision}
+        if reason := validateSignal(node, m); reason != "" {
+            signalSamples.WithLabelValues(reason).Inc()
+            continue
+        }
+        signalSamples.WithLabelValues(sampleAccepted).Inc()
+        src.state.Ingest(remoteWriteSourceName, node, WithCapacity(capacity),
+            WithSignal(signal),
+            WithOverprovision(overprovision))
+    }
+    return nil
+}
+
+// prune drops a node's series that have not been written for SeriesTTL.
+func (src *remoteWriteSource) prune(ns nodeSeries, now time.Time) {
+    cutoff := now.Add(-src.cfg.SeriesTTL.Duration).UnixMilli()
+    for key, s := range ns {
+        if s.last.ts < cutoff {
+            delete(ns, key)
+        }
+    }
+}
+
+func seriesKey(labels map[string]string) string {
+    keys := make([]string, 0, len(labels))
+    for k := range labels {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    var b strings.Builder
+    for _, k := range keys {
+        b.WriteString(k)
+        b.WriteByte(0)
+        b.WriteString(labels[k])
+        b.WriteByte(0)
+    }
+    return b.String()
+}
+
+// decodeWriteRequest walks the time series of a Prometheus remote-write
+// WriteRequest, calling fn with each series' labels and float samples.
+// Metadata, exemplars and native histograms are skipped.
+func decodeWriteRequest(b []byte, fn func(labels map[string]string, samples []seriesSample)) error {
+    return walkProto(b, func(ts protoField) error {
+        if ts.num != 1 || ts.typ != protowire.BytesType {
+            return nil
+        }
+        labels := map[string]string{}
+        var samples []seriesSample
+        err := walkProto(ts.bytes, func(f protoField) error {
+            switch {
+            case f.num == 1 && f.typ == protowire.BytesType:
+                var name, value string
+                err := walkProto(f.bytes, func(f protoField) error {
+                    switch {
+                    case f.num == 1 && f.typ == protowire.BytesType:
+                        name = string(f.bytes)
+                    case f.num == 2 && f.typ == protowire.BytesType:
+                        value = string(f.bytes)
+                    }
+                    return nil
+                })
+                labels[name] = value
+                return err
+            case f.num == 2 && f.typ == protowire.BytesType:
+                var sample seriesSample
+                err := walkProto(f.bytes, func(f protoField) error {
+                    switch {
+                    case f.num == 1 && f.typ == protowire.Fixed64Type:
+                        sample.value = math.Float64frombits(f.u)
+                    case f.num == 2 && f.typ == protowire.VarintType:
+                        sample.ts = int64(f.u)
+                    }
+                    return nil
+                })
+                samples = append(samples, sample)
+                return err
+            }
+            return nil
+        })
+        if err != nil {
+            return err
+        }
+        fn(labels, samples)
+        return nil
+    })
+}
+
+// protoField is a field of an encoded protobuf message: bytes holds the
+// payload of a length-delimited field, u the value of a varint or fixed64.
+type protoField struct {
+    num   protowire.Number
+    typ   protowire.Type
+    bytes []byte
+    u     uint64
+}
+
+// walkProto calls fn with each field of an encoded protobuf message.
+func walkProto(b []byte, fn func(f protoField) error) error {
+    for len(b) > 0 {
+        num, typ, n := protowire.ConsumeTag(b)
+        if n < 0 {
+            return protowire.ParseError(n)
+        }
+        b = b[n:]
+        f := protoField{num: num, typ: typ}
+        switch typ {
+        case protowire.BytesType:
+            f.bytes, n = protowire.ConsumeBytes(b)
+        case protowire.VarintType:
+            f.u, n = protowire.ConsumeVarint(b)
+        case protowire.Fixed64Type:
+            f.u, n = protowire.ConsumeFixed64(b)
+        default:
+            n = protowire.ConsumeFieldValue(num, typ, b)
+        }
+        if n < 0 {
+            return protowire.ParseError(n)
+        }
+        b = b[n:]
+        if err := fn(f); err != nil {
+            return err
+        }
+    }
+    return nil
+}
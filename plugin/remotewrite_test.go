@@ -0,0 +1,102 @@
+package plugin
+
+import (
+    "bytes"
+    "encoding/binary"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/go-logr/logr"
+    "github.com/golang/snappy"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/utils/clock"
+)
+
+// testWriteRequest is a WriteRequest with one series,
+// up{node="n1"} 1.5 @ 1000, followed by a metadata entry.
+var testWriteRequest = []byte("" +
+    "\x0a\x2a" + // timeseries, 42 bytes
+    "\x0a\x0e" + "\x0a\x08__name__" + "\x12\x02up" + // label
+    "\x0a\x0a" + "\x0a\x04node" + "\x12\x02n1" + // label
+    "\x12\x0c" + "\x09\x00\x00\x00\x00\x00\x00\xf8\x3f" + "\x10\xe8\x07" + // sample
+    "\x1a\x04" + "\x08\x01\x12\x00") // metadata
+
+func TestDecodeWriteRequest(t *testing.T) {
+    type decoded struct {
+        labels  map[string]string
+        samples []seriesSample
+    }
+    var got []decoded
+    err := decodeWriteRequest(testWriteRequest, func(labels map[string]string, samples []seriesSample) {
+        got = append(got, decoded{labels, samples})
+    })
+    if err != nil {
+        t.Fatalf("decodeWriteRequest: %v", err)
+    }
+    want := []decoded{{
+        labels:  map[string]string{metricNameLabel: "up", "node": "n1"},
+        samples: []seriesSample{{value: 1.5, ts: 1000}},
+    }}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("decoded %+v, want %+v", got, want)
+    }
+
+    if err := decodeWriteRequest(testWriteRequest[:20], func(map[string]string, []seriesSample) {}); err == nil {
+        t.Error("decoding a truncated request succeeded")
+    }
+}
+
+func newTestRemoteWriteSource(t *testing.T) *remoteWriteSource {
+    pl := &ProntoPlugin{args: &ProntoArgs{RemoteWriteSource: RemoteWriteSource{
+        NodeLabel: "node",
+        Signal:    "up * 2",
+        Capacity:  "up",
+        // Keep the sample of testWriteRequest, written at 1000ms.
+        SeriesTTL: metav1.Duration{Duration: time.Since(time.UnixMilli(1000)) + time.Hour},
+    }}}
+    pl.clock = clock.RealClock{}
+    pl.HostReservations = make(map[string]*HostInfo)
+    pl.sourceSamples = make(map[string]map[string]time.Time)
+    src, err := newRemoteWriteSource(pl, SourceConfig{Name: remoteWriteSourceName}, logr.Discard())
+    if err != nil {
+        t.Fatal(err)
+    }
+    return src.(*remoteWriteSource)
+}
+
+func TestServeWrite(t *testing.T) {
+    // A snappy block starts with its decoded length as a varint.
+    huge := binary.AppendUvarint(nil, maxRemoteWriteDecoded+1)
+    huge = append(huge, 0)
+
+    tests := []struct {
+        name   string
+        body   []byte
+        status int
+    }{
+        {name: "valid", body: snappy.Encode(nil, testWriteRequest), status: http.StatusNoContent},
+        {name: "not snappy", body: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, status: http.StatusBadRequest},
+        {name: "decoded length over limit", body: huge, status: http.StatusRequestEntityTooLarge},
+        {name: "not a WriteRequest", body: snappy.Encode(nil, []byte{0x0a, 0x05}), status: http.StatusBadRequest},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            src := newTestRemoteWriteSource(t)
+            rec := httptest.NewRecorder()
+            src.serveWrite(rec, httptest.NewRequest(http.MethodPost, "/write", bytes.NewReader(tt.body)))
+            if rec.Code != tt.status {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+            }
+            if tt.status != http.StatusNoContent {
+                return
+            }
+            hi := src.state.GetHost("n1")
+            if hi == nil || hi.Signal != 3 || hi.Capacity != 1.5 {
+                t.Errorf("ingested %+v, want signal 3 and capacity 1.5", hi)
+            }
+        })
+    }
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "github.com/LucaChot/pronto-framework/record"
+    "github.com/go-logr/logr"
+)
+
+const replaySourceName = "replay"
+
+// replaySource ingests a recording of the grpc source's signals directly
+// into the plugin's state, to reproduce a run without its agents.
+type replaySource struct {
+    state  *prontoState
+    cfg    ReplaySource
+    logger logr.Logger
+}
+
+func newReplaySource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    return &replaySource{
+        state:  &pl.prontoState,
+        cfg:    pl.args.ReplaySource,
+        logger: logger,
+    }, nil
+}
+
+func (src *replaySource) Name() string { return replaySourceName }
+
+// Start opens the recording and replays it in the background, once.
+func (src *replaySource) Start(ctx context.Context) error {
+    r, err := record.Open(src.cfg.Path)
+    if err != nil {
+        return fmt.Errorf("opening signal recording: %w", err)
+    }
+    go func() {
+        defer r.Close()
+        src.logger.Info("Replaying signal recording", "path", src.cfg.Path, "speed", src.cfg.Speed)
+        if err := src.replay(ctx, r); err != nil && !errors.Is(err, context.Canceled) {
+            src.logger.Error(err, "Replaying signal recording", "path", src.cfg.Path)
+            return
+        }
+        src.logger.Info("Replayed signal recording", "path", src.cfg.Path)
+    }()
+    return nil
+}
+
+// replay ingests the recording at Speed times the recorded rate or, for a
+// Speed of 0, as fast as possible.
+func (src *replaySource) replay(ctx context.Context, r *record.Reader) error {
+    return record.Replay(ctx, r, src.cfg.Speed, func(m *pb.Signal) error {
+        if reason := validateSignal(m.GetNode(), m); reason != "" {
+            signalSamples.WithLabelValues(reason).Inc()
+            return nil
+        }
+        signalSamples.WithLabelValues(sampleAccepted).Inc()
+        src.state.Ingest(replaySourceName, m.GetNode(), WithCapacity(m.GetCapacity()),
+            WithSignal(m.GetSignal()),
+            WithOverprovision(m.GetOverprovision()))
+        return nil
+    })
+}
@@ -0,0 +1,82 @@
+package plugin
+
+import (
+    "bytes"
+    "context"
+    "net"
+    "testing"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "github.com/LucaChot/pronto-framework/record"
+    "github.com/go-logr/logr"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/test/bufconn"
+)
+
+// streamSignals sends signals to the grpc source over one stream.
+func streamSignals(t *testing.T, gs *grpcSource, signals ...*pb.Signal) {
+    lis := bufconn.Listen(1 << 16)
+    srv := grpc.NewServer()
+    pb.RegisterSignalServiceServer(srv, gs)
+    go srv.Serve(lis)
+    defer srv.Stop()
+
+    conn, err := grpc.NewClient("passthrough:///scheduler",
+        grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+        grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer conn.Close()
+    stream, err := pb.NewSignalServiceClient(conn).StreamSignals(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, m := range signals {
+        if err := stream.Send(m); err != nil {
+            t.Fatal(err)
+        }
+    }
+    // Returns once the source has handled every signal. The source ends
+    // the stream without an acknowledgement, so the error is io.EOF.
+    stream.CloseAndRecv()
+}
+
+func TestRecordAndReplay(t *testing.T) {
+    var buf bytes.Buffer
+    gs := &grpcSource{state: newTestState(), recorder: record.NewWriter(&buf), logger: logr.Discard()}
+    // Only the first signal names the node.
+    streamSignals(t, gs,
+        &pb.Signal{Node: "n", Signal: 0.25, Capacity: 4},
+        &pb.Signal{Signal: 0.5, Capacity: 3},
+        &pb.Signal{Signal: 0.75, Capacity: 2, Overprovision: 1},
+    )
+
+    recording := buf.Bytes()
+    r := record.NewReader(bytes.NewReader(recording))
+    var nodes []string
+    for {
+        _, m, err := r.Next()
+        if err != nil {
+            break
+        }
+        nodes = append(nodes, m.GetNode())
+    }
+    if len(nodes) != 3 || nodes[0] != "n" || nodes[1] != "n" || nodes[2] != "n" {
+        t.Fatalf("recorded signals for nodes %q, want three for n", nodes)
+    }
+
+    // Replay the recording into a fresh scheduler.
+    src := &replaySource{state: newTestState(), cfg: ReplaySource{Speed: 0}, logger: logr.Discard()}
+    if err := src.replay(context.Background(), record.NewReader(bytes.NewReader(recording))); err != nil {
+        t.Fatal(err)
+    }
+    hi := src.state.GetHost("n")
+    if hi == nil {
+        t.Fatal("replay ingested nothing for n")
+    }
+    if hi.Samples != 3 || hi.Signal != 0.75 || hi.Capacity != 2 || hi.Overprovision != 1 || hi.Source != replaySourceName {
+        t.Errorf("replayed host = %+v, want 3 samples ending in signal 0.75, capacity 2 and overprovision 1 from %s", hi, replaySourceName)
+    }
+}
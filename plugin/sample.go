@@ -0,0 +1,100 @@
+package plugin
+
+import (
+    "context"
+    "math"
+    "sort"
+
+    v1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/util/sets"
+    "k8s.io/klog/v2"
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const sampleStateKey framework.StateKey = Name + "/sample"
+
+// sampleState records the candidate nodes chosen by PreScore. Only these
+// nodes are scored in the cycle.
+type sampleState struct {
+    nodes sets.Set[string]
+}
+
+func (s *sampleState) Clone() framework.StateData {
+    return s
+}
+
+// PreScore implements power-of-k-choices placement. Every pod in a burst sees
+// the same signals, so scoring all nodes sends the whole burst to the single
+// best node until its next signal arrives. Sampling k candidates per pod
+// spreads the burst while still preferring the better of the sampled nodes.
+func (pl *ProntoPlugin) PreScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodes []*v1.Node) *framework.Status {
+    logger := klog.FromContext(klog.NewContext(ctx, pl.logger)).WithValues("ExtensionPoint", "PreScore")
+    names := make([]string, len(nodes))
+    for i, node := range nodes {
+        names[i] = node.Name
+    }
+    sampled := pl.sampleCandidates(names, pl.judgedHost)
+    if sampled == nil {
+        return nil
+    }
+    state.Write(sampleStateKey, &sampleState{nodes: sampled})
+
+    if logger.V(10).Enabled() {
+        logger.Info("Pronto Sample", "podName", pod.Name, "k", pl.args.Sampling.K, "nodes", sets.List(sampled))
+    }
+    return nil
+}
+
+// sampleCandidates picks the configured k of the named nodes, looking up
+// their headroom with host if sampling is weighted by capacity. It returns
+// nil if every node is a candidate.
+func (pl *ProntoPlugin) sampleCandidates(names []string, host func(string) *HostInfo) sets.Set[string] {
+    k := pl.args.Sampling.K
+    if k == 0 || k >= len(names) {
+        return nil
+    }
+
+    weights := make([]float64, len(names))
+    for i, name := range names {
+        weights[i] = 1
+        if pl.args.Sampling.WeightByCapacity {
+            if hostInfo := host(name); hostInfo != nil {
+                weights[i] = math.Max(headroom(hostInfo), minSampleWeight)
+            }
+        }
+    }
+    return sets.New(pl.sampleNodes(names, weights, k)...)
+}
+
+// minSampleWeight keeps nodes with no headroom left sampleable.
+const minSampleWeight = 1e-3
+
+// sampleNodes picks k names without replacement, each with probability
+// proportional to its weight, using the Efraimidis-Spirakis method: every
+// name draws the key u^(1/w) and the k largest keys win.
+func (pl *ProntoPlugin) sampleNodes(names []string, weights []float64, k int) []string {
+    type keyed struct {
+        name string
+        key  float64
+    }
+    keys := make([]keyed, len(names))
+    for i, name := range names {
+        keys[i] = keyed{name: name, key: math.Pow(pl.rng.Float64(), 1/weights[i])}
+    }
+    sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+
+    sampled := make([]string, k)
+    for i := range sampled {
+        sampled[i] = keys[i].name
+    }
+    return sampled
+}
+
+// isSampled reports whether nodeName should be scored in this cycle.
+func isSampled(state *framework.CycleState, nodeName string) bool {
+    s, err := state.Read(sampleStateKey)
+    if err != nil {
+        return true
+    }
+    return s.(*sampleState).nodes.Has(nodeName)
+}
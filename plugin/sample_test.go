@@ -0,0 +1,121 @@
+package plugin
+
+import (
+    "context"
+    "reflect"
+    "testing"
+
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/util/sets"
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func newSamplingPlugin(seed int64, sampling Sampling, hosts map[string]*HostInfo) *ProntoPlugin {
+    pl := &ProntoPlugin{
+        args:       &ProntoArgs{Sampling: sampling},
+        rng:        newLockedRand(seed),
+        normalizer: minMaxNormalize,
+    }
+    pl.HostReservations = hosts
+    return pl
+}
+
+func TestSampleNodesSeeded(t *testing.T) {
+    names := []string{"a", "b", "c", "d"}
+    weights := []float64{1, 1, 1, 1}
+    draw := func() [][]string {
+        pl := newSamplingPlugin(42, Sampling{}, nil)
+        var draws [][]string
+        for i := 0; i < 10; i++ {
+            draws = append(draws, pl.sampleNodes(names, weights, 2))
+        }
+        return draws
+    }
+    if first, second := draw(), draw(); !reflect.DeepEqual(first, second) {
+        t.Errorf("same seed sampled %v then %v", first, second)
+    }
+}
+
+func TestSampleNodesWeightByCapacity(t *testing.T) {
+    hosts := map[string]*HostInfo{
+        "small": {Capacity: 2},
+        "large": {Capacity: 18},
+        "full":  {Capacity: 3, Reserved: 5},
+    }
+    pl := newSamplingPlugin(7, Sampling{K: 1, WeightByCapacity: true}, hosts)
+    var nodes []*v1.Node
+    for _, name := range []string{"small", "large", "full"} {
+        nodes = append(nodes, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+    }
+
+    const draws = 10000
+    counts := map[string]int{}
+    for i := 0; i < draws; i++ {
+        state := framework.NewCycleState()
+        if status := pl.PreScore(context.Background(), state, &v1.Pod{}, nodes); !status.IsSuccess() {
+            t.Fatal(status)
+        }
+        for _, node := range nodes {
+            if isSampled(state, node.Name) {
+                counts[node.Name]++
+            }
+        }
+    }
+
+    // Weights are the headroom, 2 and 18, with the full node kept at
+    // minSampleWeight.
+    if got := float64(counts["large"]) / draws; got < 0.87 || got > 0.93 {
+        t.Errorf("large sampled %.3f of the time, want about 0.9", got)
+    }
+    if got := float64(counts["small"]) / draws; got < 0.07 || got > 0.13 {
+        t.Errorf("small sampled %.3f of the time, want about 0.1", got)
+    }
+    if counts["full"] > 5 {
+        t.Errorf("full node sampled %d times, want almost never", counts["full"])
+    }
+}
+
+func TestNormalizeScoreSampled(t *testing.T) {
+    tests := []struct {
+        name    string
+        sampled []string
+        scores  framework.NodeScoreList
+        want    map[string]int64
+    }{
+        {
+            name:    "single sampled node beats the rest",
+            sampled: []string{"b"},
+            scores:  framework.NodeScoreList{{Name: "a", Score: 0}, {Name: "b", Score: 300}, {Name: "c", Score: 0}},
+            want:    map[string]int64{"a": framework.MinNodeScore, "b": framework.MinNodeScore + 1, "c": framework.MinNodeScore},
+        },
+        {
+            name:    "equal sampled scores beat the rest",
+            sampled: []string{"a", "c"},
+            scores:  framework.NodeScoreList{{Name: "a", Score: 50}, {Name: "b", Score: 0}, {Name: "c", Score: 50}},
+            want:    map[string]int64{"a": framework.MinNodeScore + 1, "b": framework.MinNodeScore, "c": framework.MinNodeScore + 1},
+        },
+        {
+            name:    "sampled nodes span the rest of the range",
+            sampled: []string{"a", "b"},
+            scores:  framework.NodeScoreList{{Name: "a", Score: 10}, {Name: "b", Score: 20}, {Name: "c", Score: 30}},
+            want:    map[string]int64{"a": framework.MinNodeScore + 1, "b": framework.MaxNodeScore, "c": framework.MinNodeScore},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            pl := newSamplingPlugin(1, Sampling{}, nil)
+            state := framework.NewCycleState()
+            state.Write(sampleStateKey, &sampleState{nodes: sets.New(tt.sampled...)})
+
+            pl.NormalizeScore(context.Background(), state, &v1.Pod{}, tt.scores)
+            got := map[string]int64{}
+            for _, s := range tt.scores {
+                got[s.Name] = s.Score
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("scores = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,138 @@
+package plugin
+
+import (
+    "fmt"
+    "math"
+    "math/rand"
+    "sync"
+    "time"
+)
+
+// scoreScale converts the floating point scores below into the integer
+// scores expected by the framework before normalization.
+const scoreScale = 100
+
+// scorer ranks a node from a snapshot of its HostInfo taken at now. Higher is
+// better.
+type scorer func(hi *HostInfo, now time.Time) float64
+
+func newScorer(s ScoringStrategy, rng *lockedRand) (scorer, error) {
+    switch s.Type {
+    case LeastLoaded:
+        return leastLoadedScorer, nil
+    case MostLoaded:
+        return mostLoadedScorer, nil
+    case Balanced:
+        return balancedScorer(s.TargetUtilization), nil
+    case Weighted:
+        return weightedScorer(*s.Weights), nil
+    case LowerConfidenceBound:
+        return lowerConfidenceBoundScorer(s.Confidence, s.DriftVariance), nil
+    case ThompsonSampling:
+        return thompsonSamplingScorer(s.DriftVariance, rng), nil
+    }
+    return nil, fmt.Errorf("unknown scoring strategy %q", s.Type)
+}
+
+// headroom is the capacity left on a node once the pods reserved on it since
+// its last signal are accounted for.
+func headroom(hi *HostInfo) float64 {
+    return hi.Capacity - float64(hi.Reserved)
+}
+
+// podCost is the capacity a pod is assumed to consume.
+const podCost = 1
+
+// fits reports whether a node has room for one more pod.
+func fits(hi *HostInfo) bool {
+    return headroom(hi) > podCost
+}
+
+// judgedHost returns a snapshot of a node's HostInfo as Filter and Score
+// judge it: a low-confidence signal has LowConfidenceMargin taken off its
+// capacity, so a coarse estimate needs more room to attract a pod than a
+// reported signal does.
+func (pl *ProntoPlugin) judgedHost(nodeName string) *HostInfo {
+    hi := pl.GetHost(nodeName)
+    if hi != nil && hi.LowConfidence {
+        hi.Capacity -= pl.args.LowConfidenceMargin
+        hi.CapacityMean -= pl.args.LowConfidenceMargin
+    }
+    return hi
+}
+
+// overHeadroom is the overprovision left on a node.
+func overHeadroom(hi *HostInfo) float64 {
+    return hi.Overprovision - float64(hi.OverReserved)
+}
+
+func leastLoadedScorer(hi *HostInfo, _ time.Time) float64 {
+    return headroom(hi)
+}
+
+func mostLoadedScorer(hi *HostInfo, _ time.Time) float64 {
+    return -headroom(hi)
+}
+
+// balancedScorer treats the signal as the node's utilization and prefers
+// nodes closest to target, whether above or below it.
+func balancedScorer(target float64) scorer {
+    return func(hi *HostInfo, _ time.Time) float64 {
+        return 1 - math.Abs(hi.Signal-target)
+    }
+}
+
+func weightedScorer(w ScoringWeights) scorer {
+    return func(hi *HostInfo, _ time.Time) float64 {
+        return w.Signal*hi.Signal +
+            w.Capacity*headroom(hi) +
+            w.Overprovision*overHeadroom(hi)
+    }
+}
+
+// lowerConfidenceBoundScorer penalises nodes whose capacity is noisy or has
+// not been refreshed recently, so an optimistic reading alone is not enough
+// to attract pods.
+func lowerConfidenceBoundScorer(k, drift float64) scorer {
+    return func(hi *HostInfo, now time.Time) float64 {
+        mean, std := capacityEstimate(hi, drift, now)
+        return mean - k*std - float64(hi.Reserved)
+    }
+}
+
+// thompsonSamplingScorer draws each node's capacity from its estimated
+// distribution. Uncertain nodes are sometimes ranked first and sometimes
+// last, which spreads a burst of pods instead of herding them onto the node
+// with the single best reading.
+func thompsonSamplingScorer(drift float64, rng *lockedRand) scorer {
+    return func(hi *HostInfo, now time.Time) float64 {
+        mean, std := capacityEstimate(hi, drift, now)
+        return mean + std*rng.NormFloat64() - float64(hi.Reserved)
+    }
+}
+
+// lockedRand is a rand.Rand that is safe to share between the goroutines the
+// framework runs Score on.
+type lockedRand struct {
+    mu  sync.Mutex
+    rng *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+    if seed == 0 {
+        seed = time.Now().UnixNano()
+    }
+    return &lockedRand{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) NormFloat64() float64 {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return r.rng.NormFloat64()
+}
+
+func (r *lockedRand) Float64() float64 {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return r.rng.Float64()
+}
@@ -0,0 +1,187 @@
+package plugin
+
+import (
+    "math/rand"
+    "reflect"
+    "sort"
+    "testing"
+    "time"
+
+    "k8s.io/utils/clock"
+)
+
+// scoredAt is the time rankedHosts are scored at.
+var scoredAt = time.Unix(10000, 0)
+
+// rankedHosts is a node set whose headroom, Capacity - Reserved, includes a
+// tie between b and d and a negative value on c. Their capacity estimates
+// are the same as Capacity, but a is noisy and d was last updated 900s
+// before scoredAt.
+var rankedHosts = map[string]*HostInfo{
+    "a": {Capacity: 10, Reserved: 2, Signal: 0.25, Overprovision: 5,
+        CapacityMean: 10, CapacityVar: 16, LastUpdate: scoredAt},
+    "b": {Capacity: 5, Reserved: 0, Signal: 0.5, Overprovision: 2,
+        CapacityMean: 5, LastUpdate: scoredAt},
+    "c": {Capacity: 3, Reserved: 5, Signal: 0.75,
+        CapacityMean: 3, LastUpdate: scoredAt},
+    "d": {Capacity: 6, Reserved: 1, Signal: 0.625, Overprovision: 1,
+        CapacityMean: 6, LastUpdate: scoredAt.Add(-900 * time.Second)},
+}
+
+// rank groups the hosts by their score at scoredAt, best first. Hosts with
+// equal scores share a group, in name order.
+func rank(sc scorer, hosts map[string]*HostInfo) [][]string {
+    scores := map[string]float64{}
+    var names []string
+    for name, hi := range hosts {
+        scores[name] = sc(hi, scoredAt)
+        names = append(names, name)
+    }
+    sort.Slice(names, func(i, j int) bool {
+        if scores[names[i]] != scores[names[j]] {
+            return scores[names[i]] > scores[names[j]]
+        }
+        return names[i] < names[j]
+    })
+
+    var groups [][]string
+    for i, name := range names {
+        if i > 0 && scores[name] == scores[names[i-1]] {
+            groups[len(groups)-1] = append(groups[len(groups)-1], name)
+            continue
+        }
+        groups = append(groups, []string{name})
+    }
+    return groups
+}
+
+func TestScoringStrategyRanking(t *testing.T) {
+    tests := []struct {
+        name     string
+        strategy ScoringStrategy
+        want     [][]string
+    }{
+        {
+            name:     "least loaded prefers headroom",
+            strategy: ScoringStrategy{Type: LeastLoaded},
+            want:     [][]string{{"a"}, {"b", "d"}, {"c"}},
+        },
+        {
+            name:     "most loaded prefers the least headroom",
+            strategy: ScoringStrategy{Type: MostLoaded},
+            want:     [][]string{{"c"}, {"b", "d"}, {"a"}},
+        },
+        {
+            name:     "balanced prefers the signal closest to target",
+            strategy: ScoringStrategy{Type: Balanced, TargetUtilization: 0.5},
+            want:     [][]string{{"b"}, {"d"}, {"a", "c"}},
+        },
+        {
+            name: "weighted combines signal, headroom and overprovision",
+            strategy: ScoringStrategy{Type: Weighted,
+                Weights: &ScoringWeights{Signal: -1, Capacity: 1, Overprovision: 0.5}},
+            want: [][]string{{"a"}, {"b"}, {"d"}, {"c"}},
+        },
+        {
+            name: "weighted by signal alone",
+            strategy: ScoringStrategy{Type: Weighted,
+                Weights: &ScoringWeights{Signal: 1}},
+            want: [][]string{{"c"}, {"d"}, {"b"}, {"a"}},
+        },
+        {
+            name:     "lower confidence bound without a margin ranks by headroom",
+            strategy: ScoringStrategy{Type: LowerConfidenceBound, DriftVariance: 0.01},
+            want:     [][]string{{"a"}, {"b", "d"}, {"c"}},
+        },
+        {
+            // a scores 10-4-2 and d, whose variance has drifted to 9,
+            // scores 6-3-1.
+            name:     "lower confidence bound penalises noisy and stale nodes",
+            strategy: ScoringStrategy{Type: LowerConfidenceBound, Confidence: 1, DriftVariance: 0.01},
+            want:     [][]string{{"b"}, {"a"}, {"d"}, {"c"}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            sc, err := newScorer(tt.strategy, newLockedRand(1))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := rank(sc, rankedHosts); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("rank = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestThompsonSampling(t *testing.T) {
+    sc, err := newScorer(ScoringStrategy{Type: ThompsonSampling, DriftVariance: 0.01}, newLockedRand(1))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // The scorer draws from the shared source in call order.
+    ref := rand.New(rand.NewSource(1))
+    for _, name := range []string{"a", "b", "c", "d"} {
+        hi := rankedHosts[name]
+        mean, std := capacityEstimate(hi, 0.01, scoredAt)
+        want := mean + std*ref.NormFloat64() - float64(hi.Reserved)
+        if got := sc(hi, scoredAt); got != want {
+            t.Errorf("%s: draw = %v, want %v", name, got, want)
+        }
+    }
+
+    // b is certain and always scores its headroom of 5; a averages 8 but
+    // has a standard deviation of 4, so it is ranked below b in some draws.
+    var aFirst int
+    for i := 0; i < 1000; i++ {
+        if got := sc(rankedHosts["b"], scoredAt); got != 5 {
+            t.Fatalf("certain node drew %v, want 5", got)
+        }
+        if sc(rankedHosts["a"], scoredAt) > 5 {
+            aFirst++
+        }
+    }
+    if aFirst < 600 || aFirst > 900 {
+        t.Errorf("a ranked above b in %d of 1000 draws, want about 773", aFirst)
+    }
+}
+
+func TestNegativeHeadroom(t *testing.T) {
+    c := rankedHosts["c"]
+    if got := headroom(c); got != -2 {
+        t.Errorf("headroom = %v, want -2", got)
+    }
+    if fits(c) {
+        t.Error("node with negative headroom fits a pod")
+    }
+    if got := leastLoadedScorer(c, time.Now()); got >= 0 {
+        t.Errorf("least loaded score = %v, want negative", got)
+    }
+}
+
+func TestLowConfidenceMargin(t *testing.T) {
+    pl := &ProntoPlugin{args: &ProntoArgs{LowConfidenceMargin: 1}}
+    pl.clock = clock.RealClock{}
+    pl.HostReservations = make(map[string]*HostInfo)
+    pl.sourceSamples = make(map[string]map[string]time.Time)
+    now := time.Now()
+
+    pl.Ingest(metricsSourceName, "n", WithCapacity(1.5), WithLowConfidence())
+    if got := pl.filterVerdict(pl.judgedHost("n"), now); got != rejectNoCapacity {
+        t.Errorf("low-confidence verdict = %q, want %q", got, rejectNoCapacity)
+    }
+    if got := leastLoadedScorer(pl.judgedHost("n"), now); got != 0.5 {
+        t.Errorf("low-confidence score = %v, want 0.5", got)
+    }
+
+    // Any later sample clears the mark unless it sets it again.
+    pl.UpdateHostInfo("n", WithCapacity(1.5))
+    hi := pl.judgedHost("n")
+    if hi.LowConfidence {
+        t.Error("UpdateHostInfo kept LowConfidence")
+    }
+    if got := pl.filterVerdict(hi, now); got != verdictFits {
+        t.Errorf("verdict = %q, want %q", got, verdictFits)
+    }
+}
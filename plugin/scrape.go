@@ -0,0 +1,257 @@
+package plugin
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net/http"
+    "sync"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "github.com/go-logr/logr"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/protobuf/encoding/protojson"
+    "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const scrapeSourceName = "scrape"
+
+// maxScrapeBody bounds the response read from an agent over HTTP.
+const maxScrapeBody = 1 << 20
+
+// scrapeBackoff delays polling a target after consecutive failures.
+type scrapeBackoff struct {
+    failures int
+    next     time.Time
+}
+
+// scrapeSource polls node agents for their signal, for agents that cannot
+// open a stream to the scheduler.
+type scrapeSource struct {
+    state     *prontoState
+    cfg       ScrapeSource
+    discovery *agentDiscovery
+    client    *http.Client
+    rng       *lockedRand
+    logger    logr.Logger
+
+    mu      sync.Mutex
+    backoff map[string]*scrapeBackoff
+    conns   map[string]*grpc.ClientConn
+}
+
+func newScrapeSource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    src := &scrapeSource{
+        state:   &pl.prontoState,
+        cfg:     pl.args.ScrapeSource,
+        client:  &http.Client{},
+        rng:     pl.rng,
+        logger:  logger,
+        backoff: make(map[string]*scrapeBackoff),
+        conns:   make(map[string]*grpc.ClientConn),
+    }
+    discovery, err := newAgentDiscovery(pl.handle, src.cfg.PodSelector, src.cfg.Namespace, src.cfg.Port)
+    if err != nil {
+        return nil, fmt.Errorf("scrapeSource: %w", err)
+    }
+    src.discovery = discovery
+    return src, nil
+}
+
+func (src *scrapeSource) Name() string { return scrapeSourceName }
+
+// Start polls the agents every Interval until ctx is done.
+func (src *scrapeSource) Start(ctx context.Context) error {
+    go func() {
+        wait.UntilWithContext(ctx, src.scrapeAll, src.cfg.Interval.Duration)
+        src.closeConns()
+    }()
+    return nil
+}
+
+// scrapeAll polls every target that is not backing off, spreading the polls
+// over the start of the round so agents are not polled in lockstep.
+func (src *scrapeSource) scrapeAll(ctx context.Context) {
+    targets, err := src.discovery.targets()
+    if err != nil {
+        src.logger.Error(err, "Listing scrape targets")
+        return
+    }
+    src.prune(targets)
+
+    sem := make(chan struct{}, src.cfg.Concurrency)
+    var wg sync.WaitGroup
+    now := time.Now()
+    for _, t := range targets {
+        if !src.due(t.address, now) {
+            continue
+        }
+        wg.Add(1)
+        go func(t agentTarget) {
+            defer wg.Done()
+            select {
+            case <-ctx.Done():
+                return
+            case <-time.After(src.jitter()):
+            }
+            select {
+            case <-ctx.Done():
+                return
+            case sem <- struct{}{}:
+            }
+            defer func() { <-sem }()
+            src.scrape(ctx, t)
+        }(t)
+    }
+    wg.Wait()
+}
+
+// jitter is the delay before a poll, up to Jitter of Interval.
+func (src *scrapeSource) jitter() time.Duration {
+    return time.Duration(src.rng.Float64() * src.cfg.Jitter * float64(src.cfg.Interval.Duration))
+}
+
+func (src *scrapeSource) scrape(ctx context.Context, t agentTarget) {
+    ctx, cancel := context.WithTimeout(ctx, src.cfg.Timeout.Duration)
+    defer cancel()
+
+    var m *pb.Signal
+    var err error
+    switch src.cfg.Protocol {
+    case ScrapeGRPC:
+        m, err = src.scrapeGRPC(ctx, t)
+    default:
+        m, err = src.scrapeHTTP(ctx, t)
+    }
+    if err != nil {
+        scrapeFailures.Inc()
+        src.failed(t.address, time.Now())
+        src.logger.V(4).Info("Scraping agent failed", "node", t.node, "address", t.address, "err", err)
+        return
+    }
+    src.succeeded(t.address)
+
+    // The target's node is authoritative; agents need not fill in Node.
+    if reason := validateSignal(t.node, m); reason != "" {
+        signalSamples.WithLabelValues(reason).Inc()
+        return
+    }
+    signalSamples.WithLabelValues(sampleAccepted).Inc()
+    src.state.Ingest(scrapeSourceName, t.node, WithCapacity(m.Capacity),
+        WithSignal(m.Signal),
+        WithOverprovision(m.Overprovision))
+}
+
+func (src *scrapeSource) scrapeHTTP(ctx context.Context, t agentTarget) (*pb.Signal, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+t.address+src.cfg.Path, nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := src.client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("unexpected status %s", resp.Status)
+    }
+    body, err := io.ReadAll(io.LimitReader(resp.Body, maxScrapeBody))
+    if err != nil {
+        return nil, err
+    }
+    m := &pb.Signal{}
+    if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, m); err != nil {
+        return nil, fmt.Errorf("decoding signal: %w", err)
+    }
+    return m, nil
+}
+
+func (src *scrapeSource) scrapeGRPC(ctx context.Context, t agentTarget) (*pb.Signal, error) {
+    conn, err := src.conn(t.address)
+    if err != nil {
+        return nil, err
+    }
+    return pb.NewSignalQueryClient(conn).GetSignal(ctx, &pb.SignalRequest{})
+}
+
+// conn returns the cached connection to an agent, creating it if needed.
+func (src *scrapeSource) conn(address string) (*grpc.ClientConn, error) {
+    src.mu.Lock()
+    defer src.mu.Unlock()
+    if conn, ok := src.conns[address]; ok {
+        return conn, nil
+    }
+    conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil {
+        return nil, err
+    }
+    src.conns[address] = conn
+    return conn, nil
+}
+
+// due reports whether a target's backoff, if any, has expired.
+func (src *scrapeSource) due(address string, now time.Time) bool {
+    src.mu.Lock()
+    defer src.mu.Unlock()
+    b, ok := src.backoff[address]
+    return !ok || !now.Before(b.next)
+}
+
+// failed doubles a target's backoff, starting from Interval and capped at
+// MaxBackoff.
+func (src *scrapeSource) failed(address string, now time.Time) {
+    src.mu.Lock()
+    defer src.mu.Unlock()
+    b, ok := src.backoff[address]
+    if !ok {
+        b = &scrapeBackoff{}
+        src.backoff[address] = b
+    }
+    b.failures++
+    delay := src.cfg.Interval.Duration
+    for i := 1; i < b.failures && delay < src.cfg.MaxBackoff.Duration; i++ {
+        delay *= 2
+    }
+    if delay > src.cfg.MaxBackoff.Duration {
+        delay = src.cfg.MaxBackoff.Duration
+    }
+    b.next = now.Add(delay)
+}
+
+func (src *scrapeSource) succeeded(address string) {
+    src.mu.Lock()
+    defer src.mu.Unlock()
+    delete(src.backoff, address)
+}
+
+// prune forgets the backoff and connections of targets that have gone.
+func (src *scrapeSource) prune(targets []agentTarget) {
+    live := make(map[string]bool, len(targets))
+    for _, t := range targets {
+        live[t.address] = true
+    }
+    src.mu.Lock()
+    defer src.mu.Unlock()
+    for address := range src.backoff {
+        if !live[address] {
+            delete(src.backoff, address)
+        }
+    }
+    for address, conn := range src.conns {
+        if !live[address] {
+            conn.Close()
+            delete(src.conns, address)
+        }
+    }
+}
+
+func (src *scrapeSource) closeConns() {
+    src.mu.Lock()
+    defer src.mu.Unlock()
+    for address, conn := range src.conns {
+        conn.Close()
+        delete(src.conns, address)
+    }
+}
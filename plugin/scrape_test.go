@@ -0,0 +1,243 @@
+package plugin
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "github.com/go-logr/logr"
+    "google.golang.org/grpc"
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    corelisters "k8s.io/client-go/listers/core/v1"
+    "k8s.io/client-go/tools/cache"
+)
+
+func testScrapeConfig(protocol ScrapeProtocol, port int32) ScrapeSource {
+    cfg := defaultProntoArgs().ScrapeSource
+    cfg.Protocol = protocol
+    cfg.Port = port
+    cfg.Path = "/signal"
+    cfg.Jitter = 0
+    return cfg
+}
+
+// newTestScrapeSource polls the named nodes, which all run their agent on
+// 127.0.0.1 at the configured port.
+func newTestScrapeSource(t *testing.T, cfg ScrapeSource, nodes ...string) *scrapeSource {
+    t.Helper()
+    indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+    for _, name := range nodes {
+        node := &v1.Node{
+            ObjectMeta: metav1.ObjectMeta{Name: name},
+            Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+                {Type: v1.NodeInternalIP, Address: "127.0.0.1"},
+            }},
+        }
+        if err := indexer.Add(node); err != nil {
+            t.Fatal(err)
+        }
+    }
+    src := &scrapeSource{
+        state: newTestState(),
+        cfg:   cfg,
+        discovery: &agentDiscovery{
+            port:  strconv.Itoa(int(cfg.Port)),
+            nodes: corelisters.NewNodeLister(indexer),
+        },
+        client:  &http.Client{},
+        rng:     newLockedRand(1),
+        logger:  logr.Discard(),
+        backoff: make(map[string]*scrapeBackoff),
+        conns:   make(map[string]*grpc.ClientConn),
+    }
+    t.Cleanup(src.closeConns)
+    return src
+}
+
+func listenerPort(t *testing.T, addr net.Addr) int32 {
+    t.Helper()
+    _, port, err := net.SplitHostPort(addr.String())
+    if err != nil {
+        t.Fatal(err)
+    }
+    p, err := strconv.Atoi(port)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return int32(p)
+}
+
+type testSignalQuery struct {
+    pb.UnimplementedSignalQueryServer
+    signal *pb.Signal
+}
+
+func (q *testSignalQuery) GetSignal(context.Context, *pb.SignalRequest) (*pb.Signal, error) {
+    return q.signal, nil
+}
+
+func TestScrapeProtocols(t *testing.T) {
+    want := &pb.Signal{Signal: 0.25, Capacity: 3, Overprovision: 0.5}
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/signal" {
+            http.NotFound(w, r)
+            return
+        }
+        fmt.Fprint(w, `{"signal": 0.25, "capacity": 3, "overprovision": 0.5, "unknown": true}`)
+    }))
+    defer server.Close()
+
+    lis, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    s := grpc.NewServer()
+    pb.RegisterSignalQueryServer(s, &testSignalQuery{signal: want})
+    go s.Serve(lis)
+    defer s.Stop()
+
+    for _, tc := range []struct {
+        protocol ScrapeProtocol
+        addr     net.Addr
+    }{
+        {ScrapeHTTP, server.Listener.Addr()},
+        {ScrapeGRPC, lis.Addr()},
+    } {
+        t.Run(string(tc.protocol), func(t *testing.T) {
+            src := newTestScrapeSource(t, testScrapeConfig(tc.protocol, listenerPort(t, tc.addr)), "n")
+            src.scrapeAll(context.Background())
+            hi := src.state.GetHost("n")
+            if hi == nil {
+                t.Fatal("the node's signal was not ingested")
+            }
+            if hi.Signal != want.Signal || hi.Capacity != want.Capacity || hi.Overprovision != want.Overprovision {
+                t.Errorf("ingested %v/%v/%v, want %v/%v/%v", hi.Signal, hi.Capacity, hi.Overprovision,
+                    want.Signal, want.Capacity, want.Overprovision)
+            }
+            if hi.Source != scrapeSourceName {
+                t.Errorf("source = %q, want %q", hi.Source, scrapeSourceName)
+            }
+        })
+    }
+}
+
+func TestScrapeConcurrency(t *testing.T) {
+    const concurrency = 2
+    var mu sync.Mutex
+    inFlight, peak := 0, 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        inFlight++
+        if inFlight > peak {
+            peak = inFlight
+        }
+        mu.Unlock()
+        time.Sleep(20 * time.Millisecond)
+        mu.Lock()
+        inFlight--
+        mu.Unlock()
+        fmt.Fprint(w, `{"capacity": 1}`)
+    }))
+    defer server.Close()
+
+    nodes := make([]string, 8)
+    for i := range nodes {
+        nodes[i] = fmt.Sprintf("n%d", i)
+    }
+    cfg := testScrapeConfig(ScrapeHTTP, listenerPort(t, server.Listener.Addr()))
+    cfg.Concurrency = concurrency
+    src := newTestScrapeSource(t, cfg, nodes...)
+    src.scrapeAll(context.Background())
+
+    if peak > concurrency {
+        t.Errorf("%d polls were in flight, want at most %d", peak, concurrency)
+    }
+    for _, name := range nodes {
+        if src.state.GetHost(name) == nil {
+            t.Errorf("node %s was not polled", name)
+        }
+    }
+}
+
+func TestScrapeJitter(t *testing.T) {
+    cfg := testScrapeConfig(ScrapeHTTP, 1)
+    cfg.Interval = metav1.Duration{Duration: time.Second}
+    src := newTestScrapeSource(t, cfg)
+    if got := src.jitter(); got != 0 {
+        t.Errorf("jitter without Jitter = %v, want 0", got)
+    }
+
+    src.cfg.Jitter = 0.5
+    var lowest, highest time.Duration = time.Hour, 0
+    for i := 0; i < 1000; i++ {
+        d := src.jitter()
+        if d < 0 || d >= 500*time.Millisecond {
+            t.Fatalf("jitter = %v, want it in [0, 500ms)", d)
+        }
+        lowest, highest = min(lowest, d), max(highest, d)
+    }
+    if lowest > 50*time.Millisecond || highest < 450*time.Millisecond {
+        t.Errorf("jitter spans [%v, %v], want it spread over [0, 500ms)", lowest, highest)
+    }
+}
+
+func TestScrapeBackoff(t *testing.T) {
+    cfg := testScrapeConfig(ScrapeHTTP, 1)
+    cfg.Interval = metav1.Duration{Duration: 5 * time.Second}
+    cfg.MaxBackoff = metav1.Duration{Duration: 30 * time.Second}
+    src := newTestScrapeSource(t, cfg)
+    now := time.Unix(0, 0)
+
+    for i, want := range []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second} {
+        src.failed("a", now)
+        if src.due("a", now.Add(want-time.Millisecond)) {
+            t.Errorf("failure %d: due before %v", i+1, want)
+        }
+        if !src.due("a", now.Add(want)) {
+            t.Errorf("failure %d: not due after %v", i+1, want)
+        }
+    }
+    if !src.due("b", now) {
+        t.Error("a target that never failed is not due")
+    }
+
+    src.succeeded("a")
+    if !src.due("a", now) {
+        t.Error("a target is still backing off after succeeding")
+    }
+
+    src.failed("a", now)
+    src.prune([]agentTarget{{node: "b", address: "b"}})
+    if !src.due("a", now) {
+        t.Error("a target that has gone is still backing off")
+    }
+}
+
+func TestScrapeFailureBacksOff(t *testing.T) {
+    var polls atomic.Int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        polls.Add(1)
+        http.Error(w, "unavailable", http.StatusServiceUnavailable)
+    }))
+    defer server.Close()
+
+    src := newTestScrapeSource(t, testScrapeConfig(ScrapeHTTP, listenerPort(t, server.Listener.Addr())), "n")
+    src.scrapeAll(context.Background())
+    src.scrapeAll(context.Background())
+    if got := polls.Load(); got != 1 {
+        t.Errorf("agent polled %d times, want 1 as it backs off after failing", got)
+    }
+    if src.state.GetHost("n") != nil {
+        t.Error("a failed poll ingested a signal")
+    }
+}
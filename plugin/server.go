@@ -2,27 +2,80 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log"
+	"math"
 	"net"
+	"time"
 
 	pb "github.com/LucaChot/pronto-framework/message"
+	"github.com/LucaChot/pronto-framework/record"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+const grpcSourceName = "grpc"
 
+// grpcSource receives the signals node agents stream to SignalService.
+type grpcSource struct {
+    state      *prontoState
+    aggregator *subspaceAggregator
+    // recorder, if set, records every accepted signal.
+    recorder   *record.Writer
+    logger     logr.Logger
 
-func (ps *prontoState) startPlacementServer(ctx context.Context, logger logr.Logger) {
+    pb.UnimplementedSignalServiceServer
+}
+
+func newGRPCSource(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error) {
+    gs := &grpcSource{
+        state:      &pl.prontoState,
+        aggregator: newSubspaceAggregator(pl.args.Aggregation, pl.clock),
+        logger:     logger,
+    }
+    if path := pl.args.Record.Path; path != "" {
+        recorder, err := record.Create(path)
+        if err != nil {
+            return nil, fmt.Errorf("opening signal recording: %w", err)
+        }
+        gs.recorder = recorder
+    }
+    return gs, nil
+}
+
+func (gs *grpcSource) Name() string { return grpcSourceName }
+
+func (gs *grpcSource) Start(ctx context.Context) error {
+    gs.startPlacementServer(ctx, gs.logger)
+    if gs.recorder != nil {
+        go func() {
+            <-ctx.Done()
+            gs.recorder.Close()
+        }()
+    }
+    return nil
+}
+
+func (gs *grpcSource) startPlacementServer(ctx context.Context, logger logr.Logger) {
     lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
         log.Fatalf("(grpc) failed to start server %s", err)
 	}
 
-	s := grpc.NewServer()
-    pb.RegisterSignalServiceServer(s, ps)
+    // Allow the keepalive pings of idle agent connections.
+	s := grpc.NewServer(grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+        MinTime:             10 * time.Second,
+        PermitWithoutStream: true,
+    }))
+    pb.RegisterSignalServiceServer(s, gs)
+    pb.RegisterAggregateMergeServer(s, gs.aggregator)
 
     log.Printf("(grpc) started server on %s", lis.Addr().String())
 
@@ -45,31 +98,80 @@ func (ps *prontoState) startPlacementServer(ctx context.Context, logger logr.Log
 
 }
 
-func (ps *prontoState) StreamSignals(stream pb.SignalService_StreamSignalsServer) error {
+func (gs *grpcSource) StreamSignals(stream pb.SignalService_StreamSignalsServer) error {
+    ps := gs.state
     var m pb.Signal
     var node string
+    ctx := streamContext(stream.Context())
+    streamsOpened.Inc()
     for {
         err := stream.RecvMsg(&m)
         if err != nil {
             if node != "" {
-                ps.UpdateHostInfo(node, WithCapacity(m.Capacity),
+                ps.Ingest(grpcSourceName, node, WithCapacity(m.Capacity),
                     WithSignal(m.Signal),
                     WithOverprovision(m.Overprovision))
             }
             if err == io.EOF {
+                streamsClosed.WithLabelValues("eof").Inc()
                 log.Printf("Client %s disconnected gracefully.", node)
                 return nil
             }
+            streamsClosed.WithLabelValues("error").Inc()
             log.Printf("Error receiving stream from client %s: %v", node, err)
 			return status.Errorf(codes.Internal, "error receiving stream: %v", err)
         }
 
         if node == "" {
             node = m.GetNode()
+            if node != "" {
+                ps.ConnectAgent(node, peerAddress(stream.Context()))
+                defer ps.DisconnectAgent(node)
+            }
+        }
+        if reason := validateSignal(node, &m); reason != "" {
+            signalSamples.WithLabelValues(reason).Inc()
+            continue
+        }
+        signalSamples.WithLabelValues(sampleAccepted).Inc()
+        if gs.recorder != nil {
+            // Agents may only name their node in the stream's first signal.
+            rec := proto.Clone(&m).(*pb.Signal)
+            rec.Node = node
+            if err := gs.recorder.Write(ps.clock.Now(), rec); err != nil {
+                gs.logger.Error(err, "Recording signal", "node", node)
+            }
         }
-        ps.UpdateHostInfo(node, WithCapacity(m.Capacity),
+        ps.agentSample(node)
+        _, span := ps.tracer.Start(sampleContext(ctx, &m), Name+"/StreamSignals", trace.WithSpanKind(trace.SpanKindServer))
+        ps.Ingest(grpcSourceName, node, WithCapacity(m.Capacity),
             WithSignal(m.Signal),
-            WithOverprovision(m.Overprovision))
+            WithOverprovision(m.Overprovision),
+            WithSampleSpan(span.SpanContext()))
+        if hostInfo := ps.GetHost(node); hostInfo != nil {
+            span.SetAttributes(hostAttributes(node, hostInfo)...)
+        }
+        span.End()
+    }
+}
+
+// validateSignal returns the reason a sample must be dropped, or "" if it can
+// be used.
+func validateSignal(node string, m *pb.Signal) string {
+    if node == "" {
+        return sampleMissingNode
+    }
+    for _, v := range []float64{m.Signal, m.Capacity, m.Overprovision} {
+        if math.IsNaN(v) || math.IsInf(v, 0) {
+            return sampleInvalidValue
+        }
     }
+    return ""
 }
 
+func peerAddress(ctx context.Context) string {
+    if p, ok := peer.FromContext(ctx); ok {
+        return p.Addr.String()
+    }
+    return ""
+}
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+    "math"
+    "sync"
+
+    v1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/labels"
+    "k8s.io/klog/v2"
+)
+
+// shadowState is the reservation ledger used in shadow mode. It tracks pods
+// bound by other schedulers the same way prontoState tracks Pronto's own
+// reservations, so shadow decisions account for pods whose load has not yet
+// shown up in a node's signal. It never touches prontoState.
+type shadowState struct {
+    mu       sync.Mutex
+    reserved map[string]int
+    pods     map[string]string
+}
+
+func newShadowState() *shadowState {
+    return &shadowState{
+        reserved: make(map[string]int),
+        pods:     make(map[string]string),
+    }
+}
+
+func (ss *shadowState) reserve(podName, nodeName string) {
+    ss.mu.Lock()
+    defer ss.mu.Unlock()
+
+    if _, ok := ss.pods[podName]; ok {
+        return
+    }
+    ss.pods[podName] = nodeName
+    ss.reserved[nodeName] += 1
+}
+
+func (ss *shadowState) unreserve(podName string) {
+    ss.mu.Lock()
+    defer ss.mu.Unlock()
+
+    if nodeName, ok := ss.pods[podName]; ok {
+        ss.reserved[nodeName] -= 1
+        if ss.reserved[nodeName] <= 0 {
+            delete(ss.reserved, nodeName)
+        }
+        delete(ss.pods, podName)
+    }
+}
+
+func (ss *shadowState) reservedOn(nodeName string) int {
+    ss.mu.Lock()
+    defer ss.mu.Unlock()
+
+    return ss.reserved[nodeName]
+}
+
+// isShadowed reports whether pod belongs to another profile and should be
+// evaluated in shadow mode.
+func (pl *ProntoPlugin) isShadowed(pod *v1.Pod) bool {
+    return pl.shadow != nil && pod.Spec.SchedulerName != pl.profileName
+}
+
+// shadowEvaluate runs Pronto's Filter, sampling and Score logic for a pod
+// that another scheduler has just bound to nodeName, and records whether
+// Pronto would have made the same choice. Bindings to nodes Pronto has no
+// signal for are counted apart from capacity violations. Bindings are not
+// affected.
+func (pl *ProntoPlugin) shadowEvaluate(pod *v1.Pod, nodeName string) {
+    logger := pl.logger.WithValues("mode", "shadow")
+    nodes, err := pl.handle.SharedInformerFactory().Core().V1().Nodes().Lister().List(labels.Everything())
+    if err != nil {
+        logger.Error(err, "Listing nodes for shadow evaluation")
+        return
+    }
+
+    // Filter, PreScore sampling and Score as in a scheduling cycle, with the
+    // pods other schedulers have bound counted as reserved.
+    now := pl.clock.Now()
+    hosts := map[string]*HostInfo{}
+    var feasible []string
+    actualVerdict := rejectNoData
+    for _, node := range nodes {
+        hostInfo := pl.judgedHost(node.Name)
+        if hostInfo != nil {
+            hostInfo.Reserved += pl.shadow.reservedOn(node.Name)
+            hosts[node.Name] = hostInfo
+        }
+        verdict := pl.filterVerdict(hostInfo, now)
+        if node.Name == nodeName {
+            actualVerdict = verdict
+        }
+        if verdict == verdictFits {
+            feasible = append(feasible, node.Name)
+        }
+    }
+    sampled := pl.sampleCandidates(feasible, func(name string) *HostInfo { return hosts[name] })
+
+    chosen := ""
+    best := math.Inf(-1)
+    for _, name := range feasible {
+        if sampled != nil && !sampled.Has(name) {
+            continue
+        }
+        if score := pl.scorer(hosts[name], now); score > best {
+            best = score
+            chosen = name
+        }
+    }
+    pl.shadow.reserve(pod.Name, nodeName)
+
+    result := shadowAgree
+    switch chosen {
+    case "":
+        result = shadowUnschedulable
+    case nodeName:
+    default:
+        result = shadowDisagree
+    }
+    shadowDecisions.WithLabelValues(result).Inc()
+    switch actualVerdict {
+    case verdictFits:
+    case rejectNoData:
+        shadowNoDataPlacements.Inc()
+    default:
+        shadowCapacityViolations.Inc()
+    }
+
+    if logger.V(4).Enabled() {
+        logger.Info("Pronto shadow decision", "pod", klog.KObj(pod), "scheduler", pod.Spec.SchedulerName,
+            "actualNode", nodeName, "prontoNode", chosen, "result", result, "actualVerdict", actualVerdict)
+    }
+}
+
+const (
+    shadowAgree         = "agree"
+    shadowDisagree      = "disagree"
+    shadowUnschedulable = "unschedulable"
+)
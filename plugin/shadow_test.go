@@ -0,0 +1,145 @@
+package plugin
+
+import (
+    "testing"
+
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/informers"
+    "k8s.io/client-go/kubernetes/fake"
+    "k8s.io/component-base/metrics/testutil"
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// testHandle is the framework.Handle of a plugin under test, which only
+// serves its informers.
+type testHandle struct {
+    framework.Handle
+    informers informers.SharedInformerFactory
+}
+
+func newTestHandle(t *testing.T, nodes ...string) *testHandle {
+    t.Helper()
+    h := &testHandle{informers: informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)}
+    indexer := h.informers.Core().V1().Nodes().Informer().GetIndexer()
+    for _, name := range nodes {
+        if err := indexer.Add(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return h
+}
+
+func (h *testHandle) SharedInformerFactory() informers.SharedInformerFactory { return h.informers }
+
+func shadowPod(name, scheduler, node string, phase v1.PodPhase) *v1.Pod {
+    return &v1.Pod{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+        Spec:       v1.PodSpec{SchedulerName: scheduler, NodeName: node},
+        Status:     v1.PodStatus{Phase: phase},
+    }
+}
+
+// shadowCounts reads the shadow counters, which tests only compare before
+// and after as they are shared.
+func shadowCounts(t *testing.T) map[string]float64 {
+    t.Helper()
+    counts := map[string]float64{}
+    for _, result := range []string{shadowAgree, shadowDisagree, shadowUnschedulable} {
+        v, err := testutil.GetCounterMetricValue(shadowDecisions.WithLabelValues(result))
+        if err != nil {
+            t.Fatal(err)
+        }
+        counts[result] = v
+    }
+    var err error
+    if counts["violations"], err = testutil.GetCounterMetricValue(shadowCapacityViolations); err != nil {
+        t.Fatal(err)
+    }
+    if counts["noData"], err = testutil.GetCounterMetricValue(shadowNoDataPlacements); err != nil {
+        t.Fatal(err)
+    }
+    return counts
+}
+
+func TestShadowEvaluation(t *testing.T) {
+    RegisterMetrics()
+    pl := &ProntoPlugin{
+        handle:      newTestHandle(t, "roomy", "full", "unknown"),
+        args:        defaultProntoArgs(),
+        scorer:      leastLoadedScorer,
+        rng:         newLockedRand(1),
+        profileName: "pronto",
+        shadow:      newShadowState(),
+    }
+    pl.prontoState = *newTestState()
+    pl.Ingest(grpcSourceName, "roomy", WithCapacity(2.5))
+    pl.Ingest(grpcSourceName, "full", WithCapacity(0.5))
+
+    // bind has the default scheduler bind a pending pod.
+    bind := func(name, node string) *v1.Pod {
+        pending := shadowPod(name, "default-scheduler", "", v1.PodPending)
+        bound := shadowPod(name, "default-scheduler", node, v1.PodPending)
+        pl.onPodUpdate(pending, bound)
+        return bound
+    }
+    before := shadowCounts(t)
+
+    a := bind("a", "roomy")
+    b := bind("b", "full")
+    c := bind("c", "unknown")
+    d := bind("d", "roomy")
+    // The pods bound to roomy leave it without room for another.
+    e := bind("e", "roomy")
+    // A later update of a bound pod is not a new decision.
+    pl.onPodUpdate(d, d)
+    // Pronto's own pods are not shadowed.
+    pl.onPodUpdate(shadowPod("own", "pronto", "", v1.PodPending), shadowPod("own", "pronto", "roomy", v1.PodPending))
+
+    after := shadowCounts(t)
+    for key, want := range map[string]float64{
+        shadowAgree:         2,
+        shadowDisagree:      2,
+        shadowUnschedulable: 1,
+        "violations":        2,
+        "noData":            1,
+    } {
+        if got := after[key] - before[key]; got != want {
+            t.Errorf("%s counted %v times, want %v", key, got, want)
+        }
+    }
+    for node, want := range map[string]int{"roomy": 3, "full": 1, "unknown": 1} {
+        if got := pl.shadow.reservedOn(node); got != want {
+            t.Errorf("%d shadow reservations on %s, want %d", got, node, want)
+        }
+    }
+    if hi := pl.GetHost("roomy"); hi.Reserved != 0 {
+        t.Errorf("shadow evaluation reserved %d pods in the plugin's own state", hi.Reserved)
+    }
+
+    // Running pods show up in the signal, and deleted pods leave, so both
+    // release their shadow reservation.
+    running := func(p *v1.Pod) {
+        r := p.DeepCopy()
+        r.Status.Phase = v1.PodRunning
+        pl.onPodUpdate(p, r)
+    }
+    running(a)
+    if got := pl.shadow.reservedOn("roomy"); got != 2 {
+        t.Errorf("%d shadow reservations on roomy after a pod started, want 2", got)
+    }
+    pl.onPodDelete(d)
+    pl.onPodDelete(e)
+    running(c)
+    pl.onPodDelete(b)
+    // Releasing twice is harmless.
+    pl.onPodDelete(d)
+    for _, node := range []string{"roomy", "full", "unknown"} {
+        if got := pl.shadow.reservedOn(node); got != 0 {
+            t.Errorf("%d shadow reservations left on %s, want 0", got, node)
+        }
+    }
+    if len(pl.shadow.pods) != 0 {
+        t.Errorf("shadow state still tracks %v", pl.shadow.pods)
+    }
+}
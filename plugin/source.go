@@ -0,0 +1,94 @@
+package plugin
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/go-logr/logr"
+)
+
+// SignalSource delivers node signals to Pronto. Each source ingests its
+// samples through prontoState.Ingest under its own name, which decides
+// whether the sample takes effect.
+type SignalSource interface {
+    // Name identifies the source in the plugin args and in HostInfo.Source.
+    Name() string
+    // Start begins ingesting samples until ctx is done.
+    Start(ctx context.Context) error
+}
+
+// sourceFactory builds a configured source for the plugin.
+type sourceFactory func(pl *ProntoPlugin, cfg SourceConfig, logger logr.Logger) (SignalSource, error)
+
+// signalSources are the sources that can be listed in ProntoArgs.Sources.
+var signalSources = map[string]sourceFactory{
+    grpcSourceName:        newGRPCSource,
+    crdSourceName:         newCRDSource,
+    annotationSourceName:  newAnnotationSource,
+    scrapeSourceName:      newScrapeSource,
+    remoteWriteSourceName: newRemoteWriteSource,
+    orcaSourceName:        newOrcaSource,
+    metricsSourceName:     newMetricsSource,
+    replaySourceName:      newReplaySource,
+}
+
+// sourcePrecedence is a configured source's rank; lower ranks win.
+type sourcePrecedence struct {
+    name   string
+    maxAge time.Duration
+}
+
+// startSignalSources builds and starts every source in the plugin args.
+func (pl *ProntoPlugin) startSignalSources(ctx context.Context, logger logr.Logger) error {
+    for _, cfg := range pl.args.Sources {
+        factory, ok := signalSources[cfg.Name]
+        if !ok {
+            return fmt.Errorf("unknown signal source %q", cfg.Name)
+        }
+        src, err := factory(pl, cfg, logger.WithValues("source", cfg.Name))
+        if err != nil {
+            return fmt.Errorf("creating signal source %q: %w", cfg.Name, err)
+        }
+        if err := src.Start(ctx); err != nil {
+            return fmt.Errorf("starting signal source %q: %w", cfg.Name, err)
+        }
+    }
+    return nil
+}
+
+// Ingest applies a sample from the named source to a node unless a source of
+// higher precedence has a fresh sample for the node. A source's sample is
+// fresh until its MaxAge has passed; a zero MaxAge keeps it fresh forever.
+func (ps *prontoState) Ingest(source, nodeName string, opts ...HostOptions) bool {
+    return ps.ingestAt(source, nodeName, ps.clock.Now(), opts...)
+}
+
+// ingestAt is Ingest for a sample taken at the given time, e.g. one read back
+// from an object that may have been written long before it was seen. The
+// node's signal age counts from that time.
+func (ps *prontoState) ingestAt(source, nodeName string, at time.Time, opts ...HostOptions) bool {
+    ps.mu.Lock()
+    defer ps.mu.Unlock()
+
+    now := ps.clock.Now()
+    samples, ok := ps.sourceSamples[nodeName]
+    if !ok {
+        samples = make(map[string]time.Time)
+        ps.sourceSamples[nodeName] = samples
+    }
+    samples[source] = at
+
+    for _, p := range ps.precedence {
+        if p.name == source {
+            break
+        }
+        if last, ok := samples[p.name]; ok && (p.maxAge == 0 || now.Sub(last) <= p.maxAge) {
+            return false
+        }
+    }
+
+    opts = append(opts, func(hi *HostInfo) { hi.Source = source })
+    ps.updateHostInfo(nodeName, at, opts...)
+    return true
+}
@@ -2,17 +2,47 @@ package plugin
 
 import (
 	"sync"
-	pb "github.com/LucaChot/pronto-framework/message"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/utils/clock"
 )
 
+// Reservation is an entry in the reservation ledger.
+type Reservation struct {
+    Node       string
+    ReservedAt time.Time
+}
+
+// AgentInfo describes a connected node agent.
+type AgentInfo struct {
+    Peer        string
+    ConnectedAt time.Time
+    Samples     int
+}
+
 // SignalState holds per-node reserved amounts for this cycle.
 type prontoState struct {
     mu sync.Mutex
-    PodReserved map[string]struct{}
-    PodOverReserved map[string]struct{}
+    // smoothing is the weight given to a new capacity sample by the
+    // per-node estimator.
+    smoothing float64
+    tracer trace.Tracer
+    // clock is read wherever the plugin needs the current time, so a
+    // simulation can run it on simulated time.
+    clock clock.PassiveClock
+    // PodReserved and PodOverReserved record where and when each pod was
+    // reserved.
+    PodReserved map[string]Reservation
+    PodOverReserved map[string]Reservation
     HostReservations map[string]*HostInfo
+    // Agents holds the node agents currently streaming signals, by node.
+    Agents map[string]*AgentInfo
 
-    pb.UnimplementedSignalServiceServer
+    // precedence lists the configured signal sources, highest first, and
+    // sourceSamples records when each source last reported each node.
+    precedence    []sourcePrecedence
+    sourceSamples map[string]map[string]time.Time
 }
 
 
@@ -21,13 +51,8 @@ func (ps *prontoState) GetHost(nodeName string) (*HostInfo) {
     defer ps.mu.Unlock()
 
     if node, ok := ps.HostReservations[nodeName]; ok {
-        return &HostInfo{
-            Reserved: node.Reserved,
-            OverReserved: node.OverReserved,
-            Signal: node.Signal,
-            Capacity: node.Capacity,
-            Overprovision: node.Overprovision,
-        }
+        hostInfo := *node
+        return &hostInfo
     }
     return nil
 }
@@ -38,36 +63,41 @@ func (ps *prontoState) ReservePod(podName, nodeName string, overProv bool) {
 
     if node, ok := ps.HostReservations[nodeName]; ok {
         if !overProv {
-            ps.PodReserved[podName] = struct{}{}
+            ps.PodReserved[podName] = Reservation{Node: nodeName, ReservedAt: ps.clock.Now()}
             node.Reserved += 1
         } else {
-            ps.PodOverReserved[podName] = struct{}{}
+            ps.PodOverReserved[podName] = Reservation{Node: nodeName, ReservedAt: ps.clock.Now()}
             node.OverReserved += 1
         }
+        node.PlacedSinceSignal += 1
     }
 }
 
-func (ps *prontoState) UnReservePod(podName, nodeName string) {
+// UnReservePod releases a pod's reservation. outcome records why, e.g.
+// because the pod is now running or because scheduling it failed.
+func (ps *prontoState) UnReservePod(podName, nodeName, outcome string) {
     ps.mu.Lock()
     defer ps.mu.Unlock()
 
-    if _, ok := ps.PodReserved[podName]; ok {
+    if r, ok := ps.PodReserved[podName]; ok {
         if node, ok := ps.HostReservations[nodeName]; ok {
             node.Reserved -= 1
         }
         delete(ps.PodReserved, podName)
+        reservationDuration.WithLabelValues(outcome).Observe(ps.clock.Since(r.ReservedAt).Seconds())
     }
 }
 
-func (ps *prontoState) UnOverReservePod(podName, nodeName string) {
+func (ps *prontoState) UnOverReservePod(podName, nodeName, outcome string) {
     ps.mu.Lock()
     defer ps.mu.Unlock()
 
-    if _, ok := ps.PodOverReserved[podName]; ok {
+    if r, ok := ps.PodOverReserved[podName]; ok {
         if node, ok := ps.HostReservations[nodeName]; ok {
             node.OverReserved -= 1
         }
         delete(ps.PodOverReserved, podName)
+        reservationDuration.WithLabelValues(outcome).Observe(ps.clock.Since(r.ReservedAt).Seconds())
     }
 }
 
@@ -85,6 +115,7 @@ func (ps *prontoState) AddNode(nodeName string) {
 
 func (ps *prontoState) deleteNode(nodeName string) {
     delete(ps.HostReservations, nodeName)
+    delete(ps.sourceSamples, nodeName)
 }
 
 func (ps *prontoState) DeleteNode(nodeName string) {
@@ -114,23 +145,79 @@ func WithOverprovision(overProvision float64) HostOptions {
     }
 }
 
+func WithSampleSpan(sc trace.SpanContext) HostOptions {
+    return func(hi *HostInfo) {
+        hi.SampleSpan = sc
+    }
+}
+
+// WithLowConfidence marks the sample as a coarse estimate.
+func WithLowConfidence() HostOptions {
+    return func(hi *HostInfo) {
+        hi.LowConfidence = true
+    }
+}
+
+// UpdateHostInfo applies a sample to a node regardless of which signal
+// sources are configured. Sources should use Ingest instead.
 func (ps *prontoState) UpdateHostInfo(name string, opts ...HostOptions) {
     ps.mu.Lock()
     defer ps.mu.Unlock()
 
+    ps.updateHostInfo(name, ps.clock.Now(), opts...)
+}
+
+func (ps *prontoState) updateHostInfo(name string, now time.Time, opts ...HostOptions) {
     node, ok := ps.HostReservations[name]
     if !ok {
         ps.addNode(name)
         node = ps.HostReservations[name]
     }
 
+    // A sample is only low-confidence if its source marks it so.
+    node.LowConfidence = false
     for _, opt := range opts {
         opt(node)
     }
+    if node.Samples > 0 {
+        placementsPerSignal.Observe(float64(node.PlacedSinceSignal))
+    }
+    node.PlacedLastInterval = node.PlacedSinceSignal
+    node.PlacedSinceSignal = 0
+    node.observe(ps.smoothing, now)
 }
 
+// hosts returns a copy of every node's HostInfo.
+func (ps *prontoState) hosts() map[string]HostInfo {
+    ps.mu.Lock()
+    defer ps.mu.Unlock()
+
+    hosts := make(map[string]HostInfo, len(ps.HostReservations))
+    for name, node := range ps.HostReservations {
+        hosts[name] = *node
+    }
+    return hosts
+}
+
+func (ps *prontoState) ConnectAgent(nodeName, peer string) {
+    ps.mu.Lock()
+    defer ps.mu.Unlock()
 
+    ps.Agents[nodeName] = &AgentInfo{Peer: peer, ConnectedAt: ps.clock.Now()}
+}
 
+func (ps *prontoState) DisconnectAgent(nodeName string) {
+    ps.mu.Lock()
+    defer ps.mu.Unlock()
 
+    delete(ps.Agents, nodeName)
+}
 
+func (ps *prontoState) agentSample(nodeName string) {
+    ps.mu.Lock()
+    defer ps.mu.Unlock()
 
+    if agent, ok := ps.Agents[nodeName]; ok {
+        agent.Samples += 1
+    }
+}
@@ -0,0 +1,43 @@
+package plugin
+
+import (
+    "testing"
+    "time"
+
+    "go.opentelemetry.io/otel/trace/noop"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/utils/clock"
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+func newTestState() *prontoState {
+    return &prontoState{
+        tracer:           noop.NewTracerProvider().Tracer(""),
+        clock:            clock.RealClock{},
+        HostReservations: make(map[string]*HostInfo),
+        Agents:           make(map[string]*AgentInfo),
+        sourceSamples:    make(map[string]map[string]time.Time),
+    }
+}
+
+func TestStateClock(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    clk := clocktesting.NewFakePassiveClock(start)
+    pl := &ProntoPlugin{args: &ProntoArgs{MaxSignalAge: metav1.Duration{Duration: time.Minute}}}
+    pl.clock = clk
+    pl.HostReservations = make(map[string]*HostInfo)
+    pl.sourceSamples = make(map[string]map[string]time.Time)
+
+    pl.Ingest(grpcSourceName, "n", WithCapacity(3))
+    if got := pl.GetHost("n").LastUpdate; !got.Equal(start) {
+        t.Fatalf("LastUpdate = %v, want the clock's %v", got, start)
+    }
+    if got := pl.filterVerdict(pl.GetHost("n"), clk.Now()); got != verdictFits {
+        t.Errorf("fresh verdict = %q, want %q", got, verdictFits)
+    }
+
+    clk.SetTime(start.Add(2 * time.Minute))
+    if got := pl.filterVerdict(pl.GetHost("n"), clk.Now()); got != rejectStale {
+        t.Errorf("verdict two minutes later = %q, want %q", got, rejectStale)
+    }
+}
@@ -0,0 +1,128 @@
+package plugin
+
+import (
+    "context"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/sdk/resource"
+    semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+    "go.opentelemetry.io/otel/trace"
+    "google.golang.org/grpc/metadata"
+    "k8s.io/component-base/tracing"
+    framework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const instrumentationName = "github.com/LucaChot/pronto-framework/plugin"
+
+// newTracerProvider returns an OTLP exporting provider if tracing is
+// configured in the plugin args, and a no-op provider otherwise. The no-op
+// provider still propagates any trace context it is given.
+func newTracerProvider(ctx context.Context, args *ProntoArgs) (tracing.TracerProvider, error) {
+    if args.Tracing == nil {
+        return tracing.NewNoopTracerProvider(), nil
+    }
+    return tracing.NewProvider(ctx, args.Tracing, nil,
+        []resource.Option{resource.WithAttributes(semconv.ServiceName("pronto-scheduler"))})
+}
+
+// Span attribute keys.
+const (
+    nodeKey          = attribute.Key("pronto.node")
+    signalKey        = attribute.Key("pronto.signal")
+    capacityKey      = attribute.Key("pronto.capacity")
+    overprovisionKey = attribute.Key("pronto.overprovision")
+    reservedKey      = attribute.Key("pronto.reserved")
+    sampleSeqKey     = attribute.Key("pronto.sample.sequence")
+    sampleTimeKey    = attribute.Key("pronto.sample.timestamp")
+    podKey           = attribute.Key("pronto.pod")
+    scoreKey         = attribute.Key("pronto.score")
+    filterVerdictKey = attribute.Key("pronto.filter.verdict")
+)
+
+// hostAttributes describe the HostInfo snapshot a decision was based on.
+func hostAttributes(nodeName string, hi *HostInfo) []attribute.KeyValue {
+    return []attribute.KeyValue{
+        nodeKey.String(nodeName),
+        signalKey.Float64(hi.Signal),
+        capacityKey.Float64(hi.Capacity),
+        overprovisionKey.Float64(hi.Overprovision),
+        reservedKey.Int(hi.Reserved),
+        sampleSeqKey.Int(hi.Samples),
+        sampleTimeKey.String(hi.LastUpdate.Format(time.RFC3339Nano)),
+    }
+}
+
+// sampleLinks links a decision to the span that ingested the signal it used,
+// so the agent's trace and the scheduling cycle's trace can be joined.
+func sampleLinks(hi *HostInfo) []trace.Link {
+    if !hi.SampleSpan.IsValid() {
+        return nil
+    }
+    return []trace.Link{{SpanContext: hi.SampleSpan}}
+}
+
+const cycleTraceKey framework.StateKey = Name + "/trace"
+
+// cycleTrace carries the span context of the cycle's PreFilter span, which
+// parents the spans of every other extension point in the cycle.
+type cycleTrace struct {
+    spanContext trace.SpanContext
+}
+
+func (c *cycleTrace) Clone() framework.StateData {
+    return c
+}
+
+// startSpan starts a span for an extension point as a child of the cycle's
+// PreFilter span.
+func (pl *ProntoPlugin) startSpan(ctx context.Context, state *framework.CycleState, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+    if c, err := state.Read(cycleTraceKey); err == nil {
+        ctx = trace.ContextWithSpanContext(ctx, c.(*cycleTrace).spanContext)
+    }
+    return pl.tracer.Start(ctx, Name+"/"+name, opts...)
+}
+
+// metadataCarrier adapts incoming gRPC metadata for trace context
+// propagation.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+    if values := metadata.MD(mc).Get(key); len(values) > 0 {
+        return values[0]
+    }
+    return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+    metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+    keys := make([]string, 0, len(mc))
+    for k := range mc {
+        keys = append(keys, k)
+    }
+    return keys
+}
+
+// streamContext returns ctx carrying the trace context an agent sent in the
+// stream's metadata, if any.
+func streamContext(ctx context.Context) context.Context {
+    md, ok := metadata.FromIncomingContext(ctx)
+    if !ok {
+        return ctx
+    }
+    return tracing.Propagators().Extract(ctx, metadataCarrier(md))
+}
+
+// sampleContext returns ctx carrying the trace context an agent sent with
+// the sample itself, if any, which takes precedence over the stream's.
+func sampleContext(ctx context.Context, m *pb.Signal) context.Context {
+    if tc := m.GetTraceContext(); len(tc) > 0 {
+        return tracing.Propagators().Extract(ctx, propagation.MapCarrier(tc))
+    }
+    return ctx
+}
@@ -0,0 +1,122 @@
+// Package record reads and writes recordings of the signals received by the
+// scheduler: length-delimited message.SignalRecord protobufs, one per
+// signal, in arrival order.
+package record
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "io"
+    "os"
+    "sync"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "google.golang.org/protobuf/encoding/protodelim"
+)
+
+// Writer appends signals to a recording. It is safe for concurrent use.
+type Writer struct {
+    mu sync.Mutex
+    w  io.Writer
+    c  io.Closer
+}
+
+// Create opens a recording for appending, creating it if needed.
+func Create(path string) (*Writer, error) {
+    f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+    if err != nil {
+        return nil, err
+    }
+    return &Writer{w: f, c: f}, nil
+}
+
+func NewWriter(w io.Writer) *Writer {
+    return &Writer{w: w}
+}
+
+// Write records a signal and the time it arrived.
+func (w *Writer) Write(arrival time.Time, m *pb.Signal) error {
+    rec := &pb.SignalRecord{ArrivalUnixNano: arrival.UnixNano(), Signal: m}
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    _, err := protodelim.MarshalTo(w.w, rec)
+    return err
+}
+
+func (w *Writer) Close() error {
+    if w.c == nil {
+        return nil
+    }
+    return w.c.Close()
+}
+
+// Reader reads a recording.
+type Reader struct {
+    r *bufio.Reader
+    c io.Closer
+}
+
+// Open opens a recording for reading.
+func Open(path string) (*Reader, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    return &Reader{r: bufio.NewReader(f), c: f}, nil
+}
+
+func NewReader(r io.Reader) *Reader {
+    return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next signal and its arrival time, or io.EOF at the end of
+// the recording.
+func (r *Reader) Next() (time.Time, *pb.Signal, error) {
+    rec := &pb.SignalRecord{}
+    if err := protodelim.UnmarshalFrom(r.r, rec); err != nil {
+        return time.Time{}, nil, err
+    }
+    return time.Unix(0, rec.ArrivalUnixNano), rec.Signal, nil
+}
+
+func (r *Reader) Close() error {
+    if r.c == nil {
+        return nil
+    }
+    return r.c.Close()
+}
+
+// Replay calls fn with each signal in the recording, keeping the recorded
+// gaps between arrivals divided by speed. A speed of 0 replays as fast as
+// possible.
+func Replay(ctx context.Context, r *Reader, speed float64, fn func(*pb.Signal) error) error {
+    var first time.Time
+    start := time.Now()
+    for {
+        arrival, m, err := r.Next()
+        if errors.Is(err, io.EOF) {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if first.IsZero() {
+            first = arrival
+        }
+        if speed > 0 {
+            due := start.Add(time.Duration(float64(arrival.Sub(first)) / speed))
+            select {
+            case <-ctx.Done():
+                return ctx.Err()
+            case <-time.After(time.Until(due)):
+            }
+        } else if err := ctx.Err(); err != nil {
+            return err
+        }
+        if err := fn(m); err != nil {
+            return err
+        }
+    }
+}
@@ -0,0 +1,168 @@
+package record
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "path/filepath"
+    "testing"
+    "time"
+
+    pb "github.com/LucaChot/pronto-framework/message"
+    "google.golang.org/protobuf/proto"
+)
+
+var start = time.Unix(1000, 0)
+
+// recording returns a recording of one signal per gap, the first arriving at
+// start and each later one gap after the one before.
+func recording(t *testing.T, gaps ...time.Duration) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    w := NewWriter(&buf)
+    arrival := start
+    for i, gap := range gaps {
+        arrival = arrival.Add(gap)
+        if err := w.Write(arrival, &pb.Signal{Node: "n", Capacity: float64(i)}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "signals.rec")
+    w, err := Create(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []*pb.Signal{
+        {Node: "a", Signal: 0.5, Capacity: 2},
+        {Node: "b", Signal: 0.25, Capacity: 4, Overprovision: 1},
+    }
+    for i, m := range want {
+        if err := w.Write(start.Add(time.Duration(i)*time.Second), m); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := w.Close(); err != nil {
+        t.Fatal(err)
+    }
+
+    r, err := Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer r.Close()
+    for i, m := range want {
+        arrival, got, err := r.Next()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !arrival.Equal(start.Add(time.Duration(i) * time.Second)) {
+            t.Errorf("signal %d arrived at %v", i, arrival)
+        }
+        if !proto.Equal(got, m) {
+            t.Errorf("signal %d = %v, want %v", i, got, m)
+        }
+    }
+    if _, _, err := r.Next(); !errors.Is(err, io.EOF) {
+        t.Errorf("Next at the end = %v, want io.EOF", err)
+    }
+}
+
+func TestReplayTruncated(t *testing.T) {
+    data := recording(t, 0, 0)
+    var replayed int
+    err := Replay(context.Background(), NewReader(bytes.NewReader(data[:len(data)-1])), 0,
+        func(*pb.Signal) error {
+            replayed++
+            return nil
+        })
+    if err == nil || errors.Is(err, io.EOF) {
+        t.Errorf("Replay of a truncated recording = %v, want an error other than io.EOF", err)
+    }
+    if replayed != 1 {
+        t.Errorf("replayed %d signals before the truncated one, want 1", replayed)
+    }
+}
+
+func TestReplaySpeed(t *testing.T) {
+    data := recording(t, 0, 200*time.Millisecond, 200*time.Millisecond)
+    tests := []struct {
+        speed    float64
+        min, max time.Duration
+    }{
+        {speed: 0, max: 100 * time.Millisecond},
+        {speed: 4, min: 100 * time.Millisecond, max: 350 * time.Millisecond},
+        {speed: 1, min: 400 * time.Millisecond},
+    }
+    for _, tt := range tests {
+        var got []float64
+        begin := time.Now()
+        err := Replay(context.Background(), NewReader(bytes.NewReader(data)), tt.speed,
+            func(m *pb.Signal) error {
+                got = append(got, m.Capacity)
+                return nil
+            })
+        elapsed := time.Since(begin)
+        if err != nil {
+            t.Fatalf("speed %v: %v", tt.speed, err)
+        }
+        if len(got) != 3 || got[0] != 0 || got[1] != 1 || got[2] != 2 {
+            t.Errorf("speed %v: replayed %v, want [0 1 2]", tt.speed, got)
+        }
+        if elapsed < tt.min || (tt.max > 0 && elapsed > tt.max) {
+            t.Errorf("speed %v: replay took %v, want between %v and %v", tt.speed, elapsed, tt.min, tt.max)
+        }
+    }
+}
+
+func TestReplayCancel(t *testing.T) {
+    // The second signal is due an hour after the first.
+    data := recording(t, 0, time.Hour)
+    ctx, cancel := context.WithCancel(context.Background())
+    var replayed int
+    done := make(chan error)
+    go func() {
+        done <- Replay(ctx, NewReader(bytes.NewReader(data)), 1, func(*pb.Signal) error {
+            replayed++
+            cancel()
+            return nil
+        })
+    }()
+    select {
+    case err := <-done:
+        if !errors.Is(err, context.Canceled) {
+            t.Errorf("Replay = %v, want context.Canceled", err)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("Replay did not return after cancellation")
+    }
+    if replayed != 1 {
+        t.Errorf("replayed %d signals, want 1", replayed)
+    }
+
+    // As fast as possible, nothing is replayed once the context is done.
+    err := Replay(ctx, NewReader(bytes.NewReader(data)), 0, func(*pb.Signal) error {
+        t.Error("replayed a signal after cancellation")
+        return nil
+    })
+    if !errors.Is(err, context.Canceled) {
+        t.Errorf("Replay = %v, want context.Canceled", err)
+    }
+}
+
+func TestReplayCallbackError(t *testing.T) {
+    stop := errors.New("stop")
+    var replayed int
+    err := Replay(context.Background(), NewReader(bytes.NewReader(recording(t, 0, 0, 0))), 0,
+        func(*pb.Signal) error {
+            replayed++
+            return stop
+        })
+    if !errors.Is(err, stop) || replayed != 1 {
+        t.Errorf("Replay = %v after %d signals, want %v after 1", err, replayed, stop)
+    }
+}
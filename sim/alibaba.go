@@ -0,0 +1,95 @@
+package sim
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "strconv"
+    "time"
+)
+
+// readAlibabaTaskTrace turns every instance of each task of batch_task.csv
+// into a pod running from the task's start to its end time, with its planned
+// CPU, in hundredths of a core, and memory, in percent of a machine:
+//
+//	task_name,instance_num,job_name,task_type,status,start_time,end_time,plan_cpu,plan_mem
+//
+// Tasks missing any of these fields are skipped.
+func readAlibabaTaskTrace(r io.Reader, cfg *TraceConfig) ([]PodSpec, error) {
+    var pods []PodSpec
+    err := readAlibabaCSV(r, 9, func(row []string) {
+        instances, err1 := strconv.Atoi(row[1])
+        start, end, ok := alibabaSpan(row[5], row[6])
+        cpu, err2 := strconv.ParseFloat(row[7], 64)
+        memory, err3 := strconv.ParseFloat(row[8], 64)
+        if err1 != nil || err2 != nil || err3 != nil || !ok {
+            return
+        }
+        for i := 0; i < instances; i++ {
+            pods = append(pods, PodSpec{
+                Name:     fmt.Sprintf("%s-%s-%d", row[2], row[0], i),
+                Arrival:  start,
+                Duration: end - start,
+                Load:     cpu / 100 * cfg.CPUScale,
+                Memory:   memory / 100 * cfg.MemoryScale,
+            })
+        }
+    })
+    return pods, err
+}
+
+// readAlibabaInstanceTrace turns each row of batch_instance.csv into a pod
+// running from its start to its end time, with its average CPU, in
+// hundredths of a core, and memory, in percent of a machine:
+//
+//	instance_name,task_name,job_name,task_type,status,start_time,end_time,machine_id,seq_no,total_seq_no,cpu_avg,cpu_max,mem_avg,mem_max
+//
+// Instances missing any of these fields are skipped.
+func readAlibabaInstanceTrace(r io.Reader, cfg *TraceConfig) ([]PodSpec, error) {
+    var pods []PodSpec
+    err := readAlibabaCSV(r, 14, func(row []string) {
+        start, end, ok := alibabaSpan(row[5], row[6])
+        cpu, err1 := strconv.ParseFloat(row[10], 64)
+        memory, err2 := strconv.ParseFloat(row[12], 64)
+        if err1 != nil || err2 != nil || !ok {
+            return
+        }
+        pods = append(pods, PodSpec{
+            Name:     fmt.Sprintf("%s-%s-%s-%s", row[2], row[1], row[0], row[8]),
+            Arrival:  start,
+            Duration: end - start,
+            Load:     cpu / 100 * cfg.CPUScale,
+            Memory:   memory / 100 * cfg.MemoryScale,
+        })
+    })
+    return pods, err
+}
+
+// readAlibabaCSV calls fn with every row of an Alibaba trace, which have no
+// header, checking each has the given number of fields.
+func readAlibabaCSV(r io.Reader, fields int, fn func(row []string)) error {
+    cr := csv.NewReader(r)
+    cr.FieldsPerRecord = fields
+    cr.ReuseRecord = true
+    for line := 1; ; line++ {
+        row, err := cr.Read()
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return fmt.Errorf("line %d: %w", line, err)
+        }
+        fn(row)
+    }
+}
+
+// alibabaSpan parses a start and end time in seconds, which the trace
+// leaves empty or zero when unknown.
+func alibabaSpan(start, end string) (time.Duration, time.Duration, bool) {
+    s, err1 := strconv.ParseInt(start, 10, 64)
+    e, err2 := strconv.ParseInt(end, 10, 64)
+    if err1 != nil || err2 != nil || s <= 0 || e <= s {
+        return 0, 0, false
+    }
+    return time.Duration(s) * time.Second, time.Duration(e) * time.Second, true
+}
@@ -0,0 +1,57 @@
+package sim
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+
+    "github.com/LucaChot/pronto-framework/plugin"
+)
+
+// readAuditTrace turns each pod of a Pronto audit log into a pod arriving at
+// its first scheduling cycle. The log records neither a pod's resources nor
+// when it finished, so every pod runs for the configured Duration with the
+// configured Load.
+//
+// Pods are named by their namespace/name, as logged, and told apart by UID.
+// As the plugin keys its state by pod name, a pod recreated under the same
+// name is renamed with a #n suffix counting its earlier incarnations, which
+// no Kubernetes name can contain.
+func readAuditTrace(r io.Reader, cfg *TraceConfig) ([]PodSpec, error) {
+    seen := map[string]bool{}
+    incarnations := map[string]int{}
+    var pods []PodSpec
+    var origin *plugin.AuditRecord
+    dec := json.NewDecoder(r)
+    for n := 0; ; n++ {
+        var record plugin.AuditRecord
+        if err := dec.Decode(&record); err == io.EOF {
+            return pods, nil
+        } else if err != nil {
+            return nil, fmt.Errorf("record %d: %w", n, err)
+        }
+        if origin == nil {
+            origin = &record
+        }
+
+        id := record.PodUID
+        if id == "" {
+            id = record.Pod
+        }
+        if seen[id] {
+            continue
+        }
+        seen[id] = true
+        name := record.Pod
+        if n := incarnations[record.Pod]; n > 0 {
+            name = fmt.Sprintf("%s#%d", record.Pod, n)
+        }
+        incarnations[record.Pod]++
+        pods = append(pods, PodSpec{
+            Name:     name,
+            Arrival:  record.Time.Sub(origin.Time),
+            Duration: cfg.Duration.Duration,
+            Load:     cfg.Load,
+        })
+    }
+}
@@ -0,0 +1,123 @@
+package sim
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "math"
+    "strconv"
+    "time"
+)
+
+// Values of borgEvent.Type.
+const (
+    borgSubmit   = 0
+    borgSchedule = 3
+    borgEvict    = 4
+    borgFail     = 5
+    borgFinish   = 6
+    borgKill     = 7
+    borgLost     = 8
+)
+
+// borgEvent is a row of the instance_events table. BigQuery exports its
+// integers as strings, so they are decoded from either.
+type borgEvent struct {
+    Time            borgInt `json:"time"`
+    Type            borgInt `json:"type"`
+    CollectionID    borgInt `json:"collection_id"`
+    InstanceIndex   borgInt `json:"instance_index"`
+    ResourceRequest *struct {
+        CPUs   float64 `json:"cpus"`
+        Memory float64 `json:"memory"`
+    } `json:"resource_request"`
+}
+
+type borgInt int64
+
+func (b *borgInt) UnmarshalJSON(data []byte) error {
+    if len(data) > 0 && data[0] == '"' {
+        var s string
+        if err := json.Unmarshal(data, &s); err != nil {
+            return err
+        }
+        data = []byte(s)
+    }
+    v, err := strconv.ParseInt(string(data), 10, 64)
+    *b = borgInt(v)
+    return err
+}
+
+type borgInstance struct {
+    submit, schedule, end int64
+    scheduled, ended      bool
+    cpus, memory          float64
+}
+
+// readBorgTrace turns each instance scheduled within the trace into a pod
+// arriving when it was submitted and running from its scheduling to its
+// first eviction, failure, completion, kill or loss. Its load and memory are
+// its last resource request before it was scheduled, which the trace
+// normalises to the largest machine. Times of 0 and MaxInt64 mark events
+// before and after the trace window.
+func readBorgTrace(r io.Reader, cfg *TraceConfig) ([]PodSpec, error) {
+    type key struct{ collection, index int64 }
+    instances := map[key]*borgInstance{}
+    dec := json.NewDecoder(r)
+    for n := 0; ; n++ {
+        var e borgEvent
+        if err := dec.Decode(&e); err == io.EOF {
+            break
+        } else if err != nil {
+            return nil, fmt.Errorf("event %d: %w", n, err)
+        }
+        if int64(e.Time) == math.MaxInt64 {
+            continue
+        }
+
+        k := key{int64(e.CollectionID), int64(e.InstanceIndex)}
+        inst, ok := instances[k]
+        if !ok {
+            inst = &borgInstance{submit: int64(e.Time)}
+            instances[k] = inst
+        }
+        if inst.ended {
+            continue
+        }
+        if req := e.ResourceRequest; req != nil && !inst.scheduled && (req.CPUs > 0 || req.Memory > 0) {
+            inst.cpus, inst.memory = req.CPUs, req.Memory
+        }
+        switch e.Type {
+        case borgSubmit:
+            if !inst.scheduled {
+                inst.submit = min(inst.submit, int64(e.Time))
+            }
+        case borgSchedule:
+            if !inst.scheduled {
+                inst.scheduled, inst.schedule = true, int64(e.Time)
+            }
+        case borgEvict, borgFail, borgFinish, borgKill, borgLost:
+            if inst.scheduled {
+                inst.ended, inst.end = true, int64(e.Time)
+            }
+        }
+    }
+
+    var pods []PodSpec
+    for k, inst := range instances {
+        if !inst.scheduled {
+            continue
+        }
+        pod := PodSpec{
+            Name:    fmt.Sprintf("borg-%d-%d", k.collection, k.index),
+            Arrival: time.Duration(inst.submit) * time.Microsecond,
+            Load:    inst.cpus * cfg.CPUScale,
+            Memory:  inst.memory * cfg.MemoryScale,
+        }
+        if inst.ended && inst.end > inst.schedule {
+            pod.Duration = time.Duration(inst.end-inst.schedule) * time.Microsecond
+        }
+        pods = append(pods, pod)
+    }
+    return pods, nil
+}
@@ -0,0 +1,160 @@
+package sim
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "sigs.k8s.io/yaml"
+)
+
+// Matrix is a set of plugin-arg variants to compare over the same workload
+// and seed. Its variants are the product of its Axes: one value of each
+// axis, with their PluginArgs merged in order over the simulation's.
+type Matrix struct {
+    // Config is the simulation config file, relative to the matrix file.
+    // The default config is used if it is empty.
+    Config string `json:"config,omitempty"`
+    Axes   []Axis `json:"axes"`
+}
+
+// Axis is a dimension of a Matrix.
+type Axis struct {
+    Name   string      `json:"name"`
+    Values []AxisValue `json:"values"`
+}
+
+// AxisValue is a named set of plugin args.
+type AxisValue struct {
+    Name       string                 `json:"name"`
+    PluginArgs map[string]interface{} `json:"pluginArgs,omitempty"`
+}
+
+// Variant is a combination of one value of each axis of a Matrix.
+type Variant struct {
+    // Name joins the names of its values with "/".
+    Name       string
+    PluginArgs map[string]interface{}
+}
+
+// Comparison is the outcome of simulating every variant of a Matrix.
+type Comparison struct {
+    Config *Config
+    Pods   int
+    // PluginSeed is the plugin's seed in every variant that does not set
+    // its own.
+    PluginSeed int64
+    Variants   []VariantResult
+}
+
+// VariantResult is the Result of a Variant.
+type VariantResult struct {
+    Variant
+    Result *Result
+}
+
+// LoadMatrix reads a YAML or JSON Matrix and the simulation config it
+// refers to.
+func LoadMatrix(path string) (*Matrix, *Config, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, nil, err
+    }
+    m := &Matrix{}
+    if err := yaml.UnmarshalStrict(data, m); err != nil {
+        return nil, nil, fmt.Errorf("decoding %s: %w", path, err)
+    }
+    if len(m.Axes) == 0 {
+        return nil, nil, fmt.Errorf("%s: at least one axis must be set", path)
+    }
+    for _, axis := range m.Axes {
+        if len(axis.Values) == 0 {
+            return nil, nil, fmt.Errorf("%s: axis %q has no values", path, axis.Name)
+        }
+    }
+
+    configPath := m.Config
+    if configPath != "" && !filepath.IsAbs(configPath) {
+        configPath = filepath.Join(filepath.Dir(path), configPath)
+    }
+    cfg, err := LoadConfig(configPath)
+    if err != nil {
+        return nil, nil, err
+    }
+    return m, cfg, nil
+}
+
+// Variants lists the variants of the Matrix, varying the last axis
+// fastest.
+func (m *Matrix) Variants() []Variant {
+    variants := []Variant{{}}
+    for _, axis := range m.Axes {
+        var next []Variant
+        for _, v := range variants {
+            for _, value := range axis.Values {
+                name := value.Name
+                if v.Name != "" {
+                    name = v.Name + "/" + name
+                }
+                args := mergeArgs(mergeArgs(nil, v.PluginArgs), value.PluginArgs)
+                next = append(next, Variant{Name: name, PluginArgs: args})
+            }
+        }
+        variants = next
+    }
+    return variants
+}
+
+// Compare runs every variant of the Matrix over the workload of cfg.
+// The workload is loaded once, so every variant schedules the same pods,
+// and each run uses the seed of cfg.
+func Compare(ctx context.Context, m *Matrix, cfg *Config, progress func(name string)) (*Comparison, error) {
+    pods, err := LoadWorkload(cfg)
+    if err != nil {
+        return nil, err
+    }
+    c := &Comparison{Config: cfg, Pods: len(pods), PluginSeed: cfg.PluginSeed()}
+    for _, v := range m.Variants() {
+        if progress != nil {
+            progress(v.Name)
+        }
+        run := *cfg
+        run.PluginArgs = mergeArgs(mergeArgs(nil, cfg.PluginArgs), v.PluginArgs)
+        result, err := Run(ctx, &run, pods)
+        if err != nil {
+            return nil, fmt.Errorf("variant %s: %w", v.Name, err)
+        }
+        c.Variants = append(c.Variants, VariantResult{Variant: v, Result: result})
+    }
+    return c, nil
+}
+
+// mergeArgs merges src into dst, recursing into objects present in both,
+// and returns dst. Neither argument's nested objects are modified.
+func mergeArgs(dst, src map[string]interface{}) map[string]interface{} {
+    if dst == nil {
+        dst = map[string]interface{}{}
+    }
+    for k, v := range src {
+        sv, ok := v.(map[string]interface{})
+        if !ok {
+            dst[k] = v
+            continue
+        }
+        dv, _ := dst[k].(map[string]interface{})
+        dst[k] = mergeArgs(mergeArgs(nil, dv), sv)
+    }
+    return dst
+}
+
+// slug turns a variant name into an identifier for HTML anchors.
+func slug(name string) string {
+    return strings.Map(func(r rune) rune {
+        if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+            return r
+        }
+        return '-'
+    }, name)
+}
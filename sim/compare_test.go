@@ -0,0 +1,48 @@
+package sim
+
+import (
+    "bytes"
+    "context"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestCompareDeterministic(t *testing.T) {
+    m := &Matrix{Axes: []Axis{{
+        Name: "strategy",
+        Values: []AxisValue{
+            {Name: "ts", PluginArgs: map[string]interface{}{
+                "scoringStrategy": map[string]interface{}{"type": "ThompsonSampling", "confidence": 1},
+            }},
+            {Name: "least-loaded", PluginArgs: map[string]interface{}{
+                "scoringStrategy": map[string]interface{}{"type": "LeastLoaded"},
+            }},
+        },
+    }}}
+    compare := func() *Comparison {
+        c, err := Compare(context.Background(), m, testConfig(), nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, v := range c.Variants {
+            deterministic(v.Result)
+        }
+        return c
+    }
+    first, second := compare(), compare()
+    if len(first.Variants) != 2 {
+        t.Fatalf("got %d variants, want 2", len(first.Variants))
+    }
+    if !reflect.DeepEqual(first, second) {
+        t.Errorf("two comparisons of the same matrix differ:\n%+v\n%+v", first.Variants, second.Variants)
+    }
+
+    var report bytes.Buffer
+    if err := first.WriteMarkdown(&report); err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(report.String(), "plugin seed 1") {
+        t.Errorf("report header does not show the plugin seed:\n%s", report.String())
+    }
+}
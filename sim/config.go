@@ -0,0 +1,237 @@
+package sim
+
+import (
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/LucaChot/pronto-framework/agent"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "sigs.k8s.io/yaml"
+)
+
+// Config describes a simulation: the cluster, how its nodes' signal responds
+// to the pods placed on them, the synthetic workload and the Pronto args.
+type Config struct {
+    // Seed seeds the workload, the load model's noise and tie-breaking
+    // between equally scored nodes. It is also the plugin's seed unless
+    // PluginArgs sets one; see PluginSeed.
+    Seed int64 `json:"seed,omitempty"`
+    // Horizon is the simulated time the simulation runs for.
+    Horizon metav1.Duration `json:"horizon,omitempty"`
+    // SampleInterval is the interval at which node utilization is sampled
+    // into the Result.
+    SampleInterval metav1.Duration `json:"sampleInterval,omitempty"`
+    // StartupDelay is the time between a pod being reserved on a node and
+    // it starting there, during which it adds no load.
+    StartupDelay metav1.Duration `json:"startupDelay,omitempty"`
+    // RetryInterval is the time an unschedulable pod waits before its next
+    // scheduling attempt.
+    RetryInterval metav1.Duration `json:"retryInterval,omitempty"`
+    // MaxAttempts is the number of scheduling attempts after which an
+    // unschedulable pod is rejected.
+    MaxAttempts int `json:"maxAttempts,omitempty"`
+    // OverloadThreshold is the utilization above which a node is counted
+    // as overloaded.
+    OverloadThreshold float64 `json:"overloadThreshold,omitempty"`
+
+    Nodes    []NodeGroup    `json:"nodes,omitempty"`
+    Load     LoadModel      `json:"load,omitempty"`
+    Workload WorkloadConfig `json:"workload,omitempty"`
+    // Trace replaces the synthetic Workload with the pods of a trace.
+    Trace TraceConfig `json:"trace,omitempty"`
+
+    // PluginArgs are the Pronto args, as in the scheduler configuration.
+    // Signal sources are disabled unless listed, as the simulation feeds
+    // the plugin itself.
+    PluginArgs map[string]interface{} `json:"pluginArgs,omitempty"`
+}
+
+// defaultPluginSeed seeds the plugin when neither Seed nor PluginArgs do, as
+// the plugin seeds itself from the wall clock when its seed is zero.
+const defaultPluginSeed = 1
+
+// PluginSeed is the seed the plugin runs with: the seed in PluginArgs, else
+// Seed, else a fixed seed, so that every run of a Config is reproducible.
+func (c *Config) PluginSeed() int64 {
+    var seed int64
+    switch v := c.PluginArgs["seed"].(type) {
+    case float64:
+        seed = int64(v)
+    case int:
+        seed = int64(v)
+    case int64:
+        seed = v
+    }
+    if seed == 0 {
+        seed = c.Seed
+    }
+    if seed == 0 {
+        seed = defaultPluginSeed
+    }
+    return seed
+}
+
+// NodeGroup is a set of identical nodes.
+type NodeGroup struct {
+    // Prefix names the group's nodes Prefix-0, Prefix-1, ...
+    Prefix string `json:"prefix,omitempty"`
+    Count  int    `json:"count,omitempty"`
+    // Capacity is the load the node can serve, in the units of the pods'
+    // load, e.g. cores.
+    Capacity float64 `json:"capacity,omitempty"`
+    // MemoryCapacity is the memory of the node, in the units of the pods'
+    // memory. Memory is not simulated on nodes without one.
+    MemoryCapacity float64 `json:"memoryCapacity,omitempty"`
+    // BaseLoad is the CPU utilization of the node without any pods, e.g.
+    // from system daemons.
+    BaseLoad float64 `json:"baseLoad,omitempty"`
+}
+
+// LoadModel turns a node's utilization into the signal its agent would
+// report. The CPU utilization is BaseLoad plus the load of the node's
+// running pods over its Capacity, and the memory utilization their memory
+// over its MemoryCapacity. The node's utilization is the larger of the two.
+// The agent observes both with Gaussian Noise and reports as agent.Model
+// does, with any utilization above 1 as pressure.
+type LoadModel struct {
+    // SignalInterval is the interval at which every node reports a signal.
+    SignalInterval metav1.Duration `json:"signalInterval,omitempty"`
+    // Noise is the standard deviation of the observed utilization.
+    Noise float64 `json:"noise,omitempty"`
+    // TargetUtilization and PodShare configure the agent.Model.
+    TargetUtilization float64 `json:"targetUtilization,omitempty"`
+    PodShare          float64 `json:"podShare,omitempty"`
+}
+
+// WorkloadConfig generates pods arriving as a Poisson process.
+type WorkloadConfig struct {
+    // ArrivalRate is the mean number of pods arriving per second.
+    ArrivalRate float64 `json:"arrivalRate,omitempty"`
+    // Duration is the distribution of the pods' run time, in seconds.
+    Duration Distribution `json:"duration,omitempty"`
+    // Load is the distribution of the pods' load, in the units of the
+    // nodes' Capacity.
+    Load Distribution `json:"load,omitempty"`
+    // Memory is the distribution of the pods' memory, in the units of the
+    // nodes' MemoryCapacity. Pods use no memory if it is not set.
+    Memory *Distribution `json:"memory,omitempty"`
+}
+
+// DistributionType is the family of a Distribution.
+type DistributionType string
+
+const (
+    Constant    DistributionType = "Constant"
+    Uniform     DistributionType = "Uniform"
+    Exponential DistributionType = "Exponential"
+    LogNormal   DistributionType = "LogNormal"
+)
+
+// Distribution is a distribution of non-negative values.
+type Distribution struct {
+    Type DistributionType `json:"type,omitempty"`
+    // Value is the value of a Constant distribution.
+    Value float64 `json:"value,omitempty"`
+    // Min and Max bound a Uniform distribution.
+    Min float64 `json:"min,omitempty"`
+    Max float64 `json:"max,omitempty"`
+    // Mean is the mean of an Exponential distribution.
+    Mean float64 `json:"mean,omitempty"`
+    // Mu and Sigma are the mean and standard deviation of the logarithm of
+    // a LogNormal distribution.
+    Mu    float64 `json:"mu,omitempty"`
+    Sigma float64 `json:"sigma,omitempty"`
+}
+
+// DefaultConfig is ten 8-core nodes loaded to about 60% by pods of
+// around half a core running for ten minutes on average.
+func DefaultConfig() *Config {
+    model := agent.DefaultModel()
+    return &Config{
+        Horizon:           metav1.Duration{Duration: time.Hour},
+        SampleInterval:    metav1.Duration{Duration: 10 * time.Second},
+        StartupDelay:      metav1.Duration{Duration: 2 * time.Second},
+        RetryInterval:     metav1.Duration{Duration: 5 * time.Second},
+        MaxAttempts:       5,
+        OverloadThreshold: 1,
+        Nodes:             []NodeGroup{{Prefix: "node", Count: 10, Capacity: 8, BaseLoad: 0.05}},
+        Load: LoadModel{
+            SignalInterval:    metav1.Duration{Duration: 5 * time.Second},
+            Noise:             0.02,
+            TargetUtilization: model.TargetUtilization,
+            PodShare:          model.PodShare,
+        },
+        Workload: WorkloadConfig{
+            ArrivalRate: 0.15,
+            Duration:    Distribution{Type: Exponential, Mean: 600},
+            Load:        Distribution{Type: Uniform, Min: 0.25, Max: 0.75},
+        },
+        Trace: defaultTraceConfig(),
+    }
+}
+
+// LoadConfig reads a YAML or JSON Config from path over DefaultConfig.
+func LoadConfig(path string) (*Config, error) {
+    cfg := DefaultConfig()
+    if path == "" {
+        return cfg, cfg.Validate()
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+        return nil, fmt.Errorf("decoding %s: %w", path, err)
+    }
+    return cfg, cfg.Validate()
+}
+
+// Validate checks the Config is usable.
+func (c *Config) Validate() error {
+    if c.Horizon.Duration <= 0 || c.SampleInterval.Duration <= 0 || c.Load.SignalInterval.Duration <= 0 {
+        return fmt.Errorf("horizon, sampleInterval and load.signalInterval must be positive")
+    }
+    if c.StartupDelay.Duration < 0 || c.RetryInterval.Duration <= 0 {
+        return fmt.Errorf("startupDelay must not be negative and retryInterval must be positive")
+    }
+    if c.MaxAttempts < 1 {
+        return fmt.Errorf("maxAttempts must be at least 1, got %d", c.MaxAttempts)
+    }
+    if len(c.Nodes) == 0 {
+        return fmt.Errorf("at least one node group must be set")
+    }
+    names := map[string]bool{}
+    for _, g := range c.Nodes {
+        if g.Prefix == "" || g.Count < 1 || g.Capacity <= 0 || g.MemoryCapacity < 0 || g.BaseLoad < 0 {
+            return fmt.Errorf("node group %q needs a prefix, a positive count and capacity and a non-negative memoryCapacity and baseLoad", g.Prefix)
+        }
+        if names[g.Prefix] {
+            return fmt.Errorf("node group prefix %q is repeated", g.Prefix)
+        }
+        names[g.Prefix] = true
+    }
+    if c.Load.Noise < 0 || c.Load.PodShare <= 0 || c.Load.TargetUtilization <= 0 || c.Load.TargetUtilization > 1 {
+        return fmt.Errorf("load.noise must not be negative, load.podShare must be positive and load.targetUtilization in (0, 1]")
+    }
+    if c.Workload.ArrivalRate < 0 {
+        return fmt.Errorf("workload.arrivalRate must not be negative, got %v", c.Workload.ArrivalRate)
+    }
+    if c.Trace.Path != "" {
+        if err := c.Trace.validate(); err != nil {
+            return fmt.Errorf("trace: %w", err)
+        }
+    }
+    if err := c.Workload.Duration.validate(); err != nil {
+        return fmt.Errorf("workload.duration: %w", err)
+    }
+    if err := c.Workload.Load.validate(); err != nil {
+        return fmt.Errorf("workload.load: %w", err)
+    }
+    if m := c.Workload.Memory; m != nil {
+        if err := m.validate(); err != nil {
+            return fmt.Errorf("workload.memory: %w", err)
+        }
+    }
+    return nil
+}
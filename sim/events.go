@@ -0,0 +1,52 @@
+package sim
+
+import (
+    "container/heap"
+    "time"
+)
+
+// event is an action at a point in simulated time. Events at the same time
+// run in the order they were scheduled.
+type event struct {
+    at  time.Duration
+    seq uint64
+    run func()
+}
+
+type eventQueue struct {
+    events []*event
+    seq    uint64
+}
+
+func (q *eventQueue) Len() int { return len(q.events) }
+
+func (q *eventQueue) Less(i, j int) bool {
+    a, b := q.events[i], q.events[j]
+    if a.at != b.at {
+        return a.at < b.at
+    }
+    return a.seq < b.seq
+}
+
+func (q *eventQueue) Swap(i, j int) { q.events[i], q.events[j] = q.events[j], q.events[i] }
+
+func (q *eventQueue) Push(x any) { q.events = append(q.events, x.(*event)) }
+
+func (q *eventQueue) Pop() any {
+    n := len(q.events)
+    e := q.events[n-1]
+    q.events[n-1] = nil
+    q.events = q.events[:n-1]
+    return e
+}
+
+// schedule queues run at the simulated time at.
+func (q *eventQueue) schedule(at time.Duration, run func()) {
+    q.seq++
+    heap.Push(q, &event{at: at, seq: q.seq, run: run})
+}
+
+// next removes and returns the earliest event.
+func (q *eventQueue) next() *event {
+    return heap.Pop(q).(*event)
+}
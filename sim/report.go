@@ -0,0 +1,295 @@
+package sim
+
+import (
+    "fmt"
+    "html"
+    "io"
+    "math"
+    "strings"
+    "time"
+)
+
+// heatmapLevels shade utilization from 0 to 1 in the ASCII heatmaps;
+// heatmapOverload marks overloaded time.
+const (
+    heatmapLevels   = " .:-=+*#%@"
+    heatmapOverload = '!'
+    heatmapColumns  = 72
+    barWidth        = 40
+)
+
+// metric is a per-variant value plotted as a bar chart.
+type metric struct {
+    title  string
+    value  func(r *Result) float64
+    format func(v float64) string
+}
+
+var reportMetrics = []metric{
+    {"Rejection rate", func(r *Result) float64 { return r.RejectionRate }, percent},
+    {"Mean utilization", func(r *Result) float64 { return r.MeanUtilization }, percent},
+    {"Overload incidents", func(r *Result) float64 { return float64(r.OverloadIncidents) }, count},
+    {"Overloaded node time", func(r *Result) float64 { return r.OverloadFraction }, percent},
+    {"Placement latency p99", func(r *Result) float64 { return r.PlacementLatency.P99.Seconds() }, secondsValue},
+}
+
+func percent(v float64) string      { return fmt.Sprintf("%.2f%%", 100*v) }
+func count(v float64) string        { return fmt.Sprintf("%.0f", v) }
+func secondsValue(v float64) string { return fmt.Sprintf("%.3fs", v) }
+
+func (c *Comparison) description() string {
+    nodes := 0
+    if len(c.Variants) > 0 {
+        nodes = len(c.Variants[0].Result.Nodes)
+    }
+    workload := "a synthetic workload"
+    if c.Config.Trace.Path != "" {
+        workload = fmt.Sprintf("the %s trace %s", c.Config.Trace.Format, c.Config.Trace.Path)
+    }
+    return fmt.Sprintf("%d variants scheduling %d pods from %s on %d nodes over %v, with seed %d, plugin seed %d and an overload threshold of %.2f.",
+        len(c.Variants), c.Pods, workload, nodes, c.Config.Horizon.Duration, c.Config.Seed, c.PluginSeed, c.Config.OverloadThreshold)
+}
+
+// summaryHeader and summaryRow are the columns of the summary table.
+var summaryHeader = []string{"Variant", "Scheduled", "Rejected", "Rejection rate", "Mean utilization",
+    "Overloaded node time", "Overload incidents", "Placement p50", "Placement p99", "Cycle mean"}
+
+func summaryRow(v VariantResult) []string {
+    r := v.Result
+    return []string{
+        v.Name,
+        fmt.Sprint(r.Scheduled),
+        fmt.Sprint(r.Rejected),
+        percent(r.RejectionRate),
+        percent(r.MeanUtilization),
+        fmt.Sprintf("%v (%s)", r.OverloadTime.Round(time.Second), percent(r.OverloadFraction)),
+        fmt.Sprint(r.OverloadIncidents),
+        r.PlacementLatency.P50.Round(time.Millisecond).String(),
+        r.PlacementLatency.P99.Round(time.Millisecond).String(),
+        r.CycleLatency.Mean.Round(time.Microsecond).String(),
+    }
+}
+
+// WriteMarkdown writes the Comparison as Markdown with ASCII plots.
+func (c *Comparison) WriteMarkdown(w io.Writer) error {
+    var b strings.Builder
+    fmt.Fprintf(&b, "# Pronto simulation comparison\n\n%s\n\n", c.description())
+
+    fmt.Fprintf(&b, "| %s |\n|", strings.Join(summaryHeader, " | "))
+    for range summaryHeader {
+        b.WriteString("---|")
+    }
+    b.WriteString("\n")
+    for _, v := range c.Variants {
+        fmt.Fprintf(&b, "| %s |\n", strings.Join(summaryRow(v), " | "))
+    }
+
+    for _, m := range reportMetrics {
+        fmt.Fprintf(&b, "\n## %s\n\n```text\n", m.title)
+        c.writeASCIIBars(&b, m)
+        b.WriteString("```\n")
+    }
+
+    fmt.Fprintf(&b, "\n## Utilization over time\n\nEach row is a node and each column about %v of simulated time, shaded by mean utilization from `%s` (0) to `%c` (1). `%c` marks a column in which the node was overloaded.\n",
+        c.columnSpan(), heatmapLevels[:1], heatmapLevels[len(heatmapLevels)-1], heatmapOverload)
+    for _, v := range c.Variants {
+        fmt.Fprintf(&b, "\n### %s\n\n```text\n", v.Name)
+        c.writeHeatmap(&b, v.Result)
+        b.WriteString("```\n")
+    }
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+func (c *Comparison) writeASCIIBars(b *strings.Builder, m metric) {
+    width, peak := 0, 0.0
+    for _, v := range c.Variants {
+        width = max(width, len(v.Name))
+        peak = math.Max(peak, m.value(v.Result))
+    }
+    for _, v := range c.Variants {
+        value := m.value(v.Result)
+        n := 0
+        if peak > 0 {
+            n = int(math.Round(value / peak * barWidth))
+        }
+        fmt.Fprintf(b, "%-*s |%-*s %s\n", width, v.Name, barWidth, strings.Repeat("#", n), m.format(value))
+    }
+}
+
+// columns groups sample indices into at most heatmapColumns columns.
+func columns(samples int) [][2]int {
+    n := min(samples, heatmapColumns)
+    cols := make([][2]int, n)
+    for i := range cols {
+        cols[i] = [2]int{i * samples / n, (i + 1) * samples / n}
+    }
+    return cols
+}
+
+func (c *Comparison) columnSpan() time.Duration {
+    if len(c.Variants) == 0 {
+        return 0
+    }
+    samples := len(c.Variants[0].Result.Samples)
+    if samples == 0 {
+        return 0
+    }
+    span := time.Duration(samples) * c.Config.SampleInterval.Duration / time.Duration(min(samples, heatmapColumns))
+    return span.Round(time.Second)
+}
+
+func (c *Comparison) writeHeatmap(b *strings.Builder, r *Result) {
+    width := 0
+    for _, node := range r.Nodes {
+        width = max(width, len(node))
+    }
+    cols := columns(len(r.Samples))
+    for i, node := range r.Nodes {
+        fmt.Fprintf(b, "%-*s |", width, node)
+        for _, col := range cols {
+            var sum float64
+            overloaded := false
+            for _, s := range r.Samples[col[0]:col[1]] {
+                sum += s.Utilization[i]
+                overloaded = overloaded || s.Utilization[i] > c.Config.OverloadThreshold
+            }
+            if overloaded {
+                b.WriteRune(heatmapOverload)
+                continue
+            }
+            mean := sum / float64(col[1]-col[0])
+            level := int(math.Round(clamp01(mean) * float64(len(heatmapLevels)-1)))
+            b.WriteByte(heatmapLevels[level])
+        }
+        b.WriteString("|\n")
+    }
+    if len(cols) > 0 {
+        end := r.Samples[len(r.Samples)-1].Time
+        fmt.Fprintf(b, "%-*s  0%*v\n", width, "", len(cols)-1, end)
+    }
+}
+
+// WriteHTML writes the Comparison as a standalone HTML page with SVG
+// plots.
+func (c *Comparison) WriteHTML(w io.Writer) error {
+    var b strings.Builder
+    b.WriteString(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Pronto simulation comparison</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+svg { display: block; margin: 0.5em 0 1.5em; }
+svg text { font-size: 11px; }
+</style>
+</head>
+<body>
+<h1>Pronto simulation comparison</h1>
+`)
+    fmt.Fprintf(&b, "<p>%s</p>\n<table>\n<tr>", html.EscapeString(c.description()))
+    for _, h := range summaryHeader {
+        fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(h))
+    }
+    b.WriteString("</tr>\n")
+    for _, v := range c.Variants {
+        b.WriteString("<tr>")
+        for i, cell := range summaryRow(v) {
+            if i == 0 {
+                fmt.Fprintf(&b, `<td><a href="#%s">%s</a></td>`, slug(v.Name), html.EscapeString(cell))
+                continue
+            }
+            fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(cell))
+        }
+        b.WriteString("</tr>\n")
+    }
+    b.WriteString("</table>\n")
+
+    for _, m := range reportMetrics {
+        fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(m.title))
+        c.writeSVGBars(&b, m)
+    }
+
+    b.WriteString("<h2>Utilization over time</h2>\n<p>One line per node, with the mean across nodes in black and the overload threshold dashed in red.</p>\n")
+    for _, v := range c.Variants {
+        fmt.Fprintf(&b, "<h3 id=\"%s\">%s</h3>\n", slug(v.Name), html.EscapeString(v.Name))
+        c.writeSVGUtilization(&b, v.Result)
+    }
+    b.WriteString("</body>\n</html>\n")
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+func (c *Comparison) writeSVGBars(b *strings.Builder, m metric) {
+    const label, bar, row = 260, 360, 22
+    peak := 0.0
+    for _, v := range c.Variants {
+        peak = math.Max(peak, m.value(v.Result))
+    }
+    fmt.Fprintf(b, `<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">`+"\n", label+bar+80, row*len(c.Variants))
+    for i, v := range c.Variants {
+        value := m.value(v.Result)
+        width := 0.0
+        if peak > 0 {
+            width = value / peak * bar
+        }
+        y := i * row
+        fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, label-6, y+15, html.EscapeString(v.Name))
+        fmt.Fprintf(b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="steelblue"/>`, label, y+4, width, row-8)
+        fmt.Fprintf(b, `<text x="%.1f" y="%d">%s</text>`+"\n", float64(label)+width+6, y+15, m.format(value))
+    }
+    b.WriteString("</svg>\n")
+}
+
+func (c *Comparison) writeSVGUtilization(b *strings.Builder, r *Result) {
+    const width, height = 760, 260
+    const left, right, top, bottom = 44, 12, 10, 26
+    if len(r.Samples) == 0 {
+        b.WriteString("<p>No samples.</p>\n")
+        return
+    }
+
+    yMax := math.Max(1, c.Config.OverloadThreshold)
+    for _, s := range r.Samples {
+        for _, u := range s.Utilization {
+            yMax = math.Max(yMax, u)
+        }
+    }
+    yMax = math.Ceil(yMax*4) / 4
+    horizon := c.Config.Horizon.Duration.Seconds()
+    x := func(t time.Duration) float64 { return left + t.Seconds()/horizon*(width-left-right) }
+    y := func(u float64) float64 { return top + (1-u/yMax)*(height-top-bottom) }
+
+    fmt.Fprintf(b, `<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">`+"\n", width, height)
+    for u := 0.0; u <= yMax+1e-9; u += 0.25 {
+        fmt.Fprintf(b, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" stroke="#eee"/>`, left, width-right, y(u), y(u))
+        fmt.Fprintf(b, `<text x="%d" y="%.1f" text-anchor="end">%.2f</text>`+"\n", left-4, y(u)+4, u)
+    }
+    for i := 0; i <= 4; i++ {
+        t := time.Duration(float64(c.Config.Horizon.Duration) * float64(i) / 4)
+        fmt.Fprintf(b, `<text x="%.1f" y="%d" text-anchor="middle">%v</text>`+"\n", x(t), height-8, t.Round(time.Second))
+    }
+
+    mean := make([]float64, len(r.Samples))
+    for node := range r.Nodes {
+        hue := node * 360 / len(r.Nodes)
+        fmt.Fprintf(b, `<polyline fill="none" stroke="hsl(%d,60%%,50%%)" stroke-opacity="0.6" points="`, hue)
+        for i, s := range r.Samples {
+            fmt.Fprintf(b, "%.1f,%.1f ", x(s.Time), y(s.Utilization[node]))
+            mean[i] += s.Utilization[node] / float64(len(r.Nodes))
+        }
+        b.WriteString(`"/>` + "\n")
+    }
+    b.WriteString(`<polyline fill="none" stroke="black" stroke-width="2" points="`)
+    for i, s := range r.Samples {
+        fmt.Fprintf(b, "%.1f,%.1f ", x(s.Time), y(mean[i]))
+    }
+    b.WriteString(`"/>` + "\n")
+    threshold := y(c.Config.OverloadThreshold)
+    fmt.Fprintf(b, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" stroke="red" stroke-dasharray="6,4"/>`+"\n", left, width-right, threshold, threshold)
+    b.WriteString("</svg>\n")
+}
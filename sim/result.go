@@ -0,0 +1,120 @@
+package sim
+
+import (
+    "fmt"
+    "io"
+    "sort"
+    "time"
+)
+
+// Result are the metrics of a simulation.
+type Result struct {
+    // Nodes are the simulated nodes, in the order of each Sample's
+    // Utilization.
+    Nodes []string `json:"nodes"`
+
+    // Pods is the number of pods submitted, of which Scheduled were placed
+    // and Rejected exhausted their attempts. The rest were still pending
+    // at the horizon.
+    Pods      int `json:"pods"`
+    Scheduled int `json:"scheduled"`
+    Rejected  int `json:"rejected"`
+    // Attempts is the number of scheduling cycles, of which Unschedulable
+    // found no node.
+    Attempts      int `json:"attempts"`
+    Unschedulable int `json:"unschedulable"`
+    // RejectionRate is the fraction of submitted pods that were rejected.
+    RejectionRate float64 `json:"rejectionRate"`
+
+    // MeanUtilization is the time-weighted utilization averaged over the
+    // nodes.
+    MeanUtilization float64 `json:"meanUtilization"`
+    // OverloadTime is the node time spent above the overload threshold,
+    // and OverloadFraction its share of the total node time.
+    OverloadTime     time.Duration `json:"overloadTime"`
+    OverloadFraction float64       `json:"overloadFraction"`
+    // OverloadIncidents counts the times a node crossed the threshold.
+    OverloadIncidents int `json:"overloadIncidents"`
+
+    // PlacementLatency is the simulated time from a pod's arrival to its
+    // reservation, including the time spent waiting to be retried.
+    PlacementLatency Latency `json:"placementLatency"`
+    // CycleLatency is the wall time of a scheduling cycle.
+    CycleLatency Latency `json:"cycleLatency"`
+
+    Samples []Sample `json:"samples,omitempty"`
+}
+
+// Latency summarises a latency distribution.
+type Latency struct {
+    Mean time.Duration `json:"mean"`
+    P50  time.Duration `json:"p50"`
+    P90  time.Duration `json:"p90"`
+    P99  time.Duration `json:"p99"`
+    Max  time.Duration `json:"max"`
+}
+
+// Sample is the utilization of every node at a point in simulated time.
+type Sample struct {
+    Time        time.Duration `json:"time"`
+    Utilization []float64     `json:"utilization"`
+}
+
+func (r *Result) summarize(s *simulator) {
+    if r.Pods > 0 {
+        r.RejectionRate = float64(r.Rejected) / float64(r.Pods)
+    }
+    horizon := s.cfg.Horizon.Duration
+    for _, n := range s.nodes {
+        r.MeanUtilization += n.busy / horizon.Seconds()
+        r.OverloadTime += n.overloaded
+        r.OverloadIncidents += n.incidents
+    }
+    nodeTime := time.Duration(len(s.nodes)) * horizon
+    r.MeanUtilization /= float64(len(s.nodes))
+    r.OverloadFraction = r.OverloadTime.Seconds() / nodeTime.Seconds()
+    r.PlacementLatency = summarizeLatency(s.placement)
+    r.CycleLatency = summarizeLatency(s.cycles)
+}
+
+func summarizeLatency(latencies []time.Duration) Latency {
+    if len(latencies) == 0 {
+        return Latency{}
+    }
+    sorted := append([]time.Duration(nil), latencies...)
+    sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+    var total time.Duration
+    for _, l := range sorted {
+        total += l
+    }
+    quantile := func(q float64) time.Duration {
+        return sorted[int(q*float64(len(sorted)-1))]
+    }
+    return Latency{
+        Mean: total / time.Duration(len(sorted)),
+        P50:  quantile(0.5),
+        P90:  quantile(0.9),
+        P99:  quantile(0.99),
+        Max:  sorted[len(sorted)-1],
+    }
+}
+
+// WriteSummary writes the headline metrics of the Result to w.
+func (r *Result) WriteSummary(w io.Writer) error {
+    _, err := fmt.Fprintf(w, `pods:               %d submitted, %d scheduled, %d rejected, %d pending
+attempts:           %d, %d unschedulable
+rejection rate:     %.2f%%
+mean utilization:   %.2f%%
+overload:           %.2f%% of node time, %d incidents
+placement latency:  mean %v, p50 %v, p99 %v
+cycle latency:      mean %v, p99 %v
+`,
+        r.Pods, r.Scheduled, r.Rejected, r.Pods-r.Scheduled-r.Rejected,
+        r.Attempts, r.Unschedulable,
+        100*r.RejectionRate,
+        100*r.MeanUtilization,
+        100*r.OverloadFraction, r.OverloadIncidents,
+        r.PlacementLatency.Mean, r.PlacementLatency.P50, r.PlacementLatency.P99,
+        r.CycleLatency.Mean, r.CycleLatency.P99)
+    return err
+}
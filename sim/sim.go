@@ -0,0 +1,392 @@
+// Package sim is a discrete-event simulator that drives the Pronto plugin
+// through a scheduler framework built over fake informers, so scheduling
+// policies can be evaluated without a cluster.
+//
+// Simulated nodes report signals computed by a LoadModel from the load of
+// the pods placed on them, and pods arrive and run as described by a list of
+// PodSpecs. Every arriving pod goes through PreFilter, Filter, PostFilter,
+// Score, Reserve and PostBind as in the scheduler, and is started on the chosen node
+// after a startup delay. The plugin runs on a fake clock that follows
+// simulated time, so its signal ages and the drift of its estimates see the
+// run as it would unfold in a cluster.
+package sim
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "math"
+    "math/rand"
+    "time"
+
+    "github.com/LucaChot/pronto-framework/agent"
+    "github.com/LucaChot/pronto-framework/plugin"
+    v1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/client-go/informers"
+    "k8s.io/client-go/kubernetes/fake"
+    "k8s.io/client-go/tools/events"
+    "k8s.io/klog/v2"
+    "k8s.io/kubernetes/pkg/scheduler/apis/config"
+    "k8s.io/kubernetes/pkg/scheduler/framework"
+    "k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+    "k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+    frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+    clocktesting "k8s.io/utils/clock/testing"
+)
+
+// SchedulerName is the scheduler name of the simulated profile and its pods.
+const SchedulerName = "pronto-sim"
+
+// sourceName is the signal source the simulated agents report as.
+const sourceName = "sim"
+
+// epoch is the time the plugin's clock reads at the start of a run, fixed so
+// runs with the same seed are reproducible.
+var epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type simNode struct {
+    node     *v1.Node
+    info     *framework.NodeInfo
+    capacity float64
+    memory   float64
+    base     float64
+    load     float64
+    used     float64
+
+    // since is the simulated time up to which utilization and overload
+    // have been accounted for.
+    since      time.Duration
+    busy       float64
+    overloaded time.Duration
+    incidents  int
+}
+
+func (n *simNode) cpu() float64 {
+    return n.base + n.load/n.capacity
+}
+
+// memoryUtilization is zero for nodes without a memory capacity.
+func (n *simNode) memoryUtilization() float64 {
+    if n.memory == 0 {
+        return 0
+    }
+    return n.used / n.memory
+}
+
+func (n *simNode) utilization() float64 {
+    return math.Max(n.cpu(), n.memoryUtilization())
+}
+
+type simPod struct {
+    spec     PodSpec
+    pod      *v1.Pod
+    attempts int
+}
+
+type simulator struct {
+    cfg       *Config
+    ctx       context.Context
+    fwk       framework.Framework
+    pl        *plugin.ProntoPlugin
+    rng       *rand.Rand
+    model     agent.Model
+    queue     eventQueue
+    now       time.Duration
+    clock     *clocktesting.FakePassiveClock
+    nodes     []*simNode
+    byName    map[string]*simNode
+    result    *Result
+    placement []time.Duration
+    cycles    []time.Duration
+}
+
+// Run simulates pods arriving at the cluster described by cfg and returns
+// the resulting metrics.
+func Run(ctx context.Context, cfg *Config, pods []PodSpec) (*Result, error) {
+    if err := cfg.Validate(); err != nil {
+        return nil, err
+    }
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    s := &simulator{
+        cfg:    cfg,
+        ctx:    ctx,
+        rng:    rand.New(rand.NewSource(cfg.Seed)),
+        model:  agent.Model{TargetUtilization: cfg.Load.TargetUtilization, PodShare: cfg.Load.PodShare},
+        byName: map[string]*simNode{},
+        result: &Result{},
+        clock:  clocktesting.NewFakePassiveClock(epoch),
+    }
+    for _, g := range cfg.Nodes {
+        for i := 0; i < g.Count; i++ {
+            node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", g.Prefix, i)}}
+            info := framework.NewNodeInfo()
+            info.SetNode(node)
+            n := &simNode{node: node, info: info, capacity: g.Capacity, memory: g.MemoryCapacity, base: g.BaseLoad}
+            s.nodes = append(s.nodes, n)
+            s.byName[node.Name] = n
+            s.result.Nodes = append(s.result.Nodes, node.Name)
+        }
+    }
+    if err := s.buildFramework(); err != nil {
+        return nil, err
+    }
+
+    for _, n := range s.nodes {
+        s.pl.AddNode(n.node.Name)
+        // Agents run before the workload starts, so every node has reported
+        // by the time the first pod arrives.
+        s.observe(n)
+        n := n
+        phase := time.Duration(s.rng.Int63n(int64(cfg.Load.SignalInterval.Duration)))
+        s.queue.schedule(phase, func() { s.report(n) })
+    }
+    for i := range pods {
+        p := &simPod{spec: pods[i], pod: newPod(pods[i])}
+        s.queue.schedule(p.spec.Arrival, func() { s.attempt(p) })
+    }
+    s.result.Pods = len(pods)
+    s.queue.schedule(0, s.sample)
+
+    for s.queue.Len() > 0 {
+        e := s.queue.next()
+        if e.at > cfg.Horizon.Duration {
+            break
+        }
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+        s.now = e.at
+        s.clock.SetTime(epoch.Add(e.at))
+        e.run()
+    }
+    s.now = cfg.Horizon.Duration
+    for _, n := range s.nodes {
+        s.account(n)
+    }
+    s.result.summarize(s)
+    return s.result, nil
+}
+
+// buildFramework builds a framework running only Pronto, with its signal
+// sources disabled so the simulation alone feeds it signals. The queue sort
+// and bind plugins every profile needs are never run.
+func (s *simulator) buildFramework() error {
+    args := map[string]interface{}{"sources": []interface{}{}}
+    for k, v := range s.cfg.PluginArgs {
+        args[k] = v
+    }
+    args["seed"] = s.cfg.PluginSeed()
+    raw, err := json.Marshal(args)
+    if err != nil {
+        return fmt.Errorf("encoding %s args: %w", plugin.Name, err)
+    }
+
+    enabled := config.PluginSet{Enabled: []config.Plugin{{Name: plugin.Name}}}
+    profile := &config.KubeSchedulerProfile{
+        SchedulerName: SchedulerName,
+        Plugins: &config.Plugins{
+            QueueSort:  config.PluginSet{Enabled: []config.Plugin{{Name: queuesort.Name}}},
+            PreFilter:  enabled,
+            Filter:     enabled,
+            PostFilter: enabled,
+            PreScore:   enabled,
+            Score:      config.PluginSet{Enabled: []config.Plugin{{Name: plugin.Name, Weight: 1}}},
+            Reserve:    enabled,
+            Bind:       config.PluginSet{Enabled: []config.Plugin{{Name: defaultbinder.Name}}},
+            PostBind:   enabled,
+        },
+        PluginConfig: []config.PluginConfig{{
+            Name: plugin.Name,
+            Args: &runtime.Unknown{Raw: raw, ContentType: runtime.ContentTypeJSON},
+        }},
+    }
+    registry := frameworkruntime.Registry{
+        queuesort.Name:     queuesort.New,
+        defaultbinder.Name: defaultbinder.New,
+        plugin.Name: func(ctx context.Context, obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+            p, err := plugin.NewWithClock(s.clock)(ctx, obj, handle)
+            if err != nil {
+                return nil, err
+            }
+            s.pl = p.(*plugin.ProntoPlugin)
+            return p, nil
+        },
+    }
+
+    client := fake.NewSimpleClientset()
+    fwk, err := frameworkruntime.NewFramework(s.ctx, registry, profile,
+        frameworkruntime.WithClientSet(client),
+        frameworkruntime.WithInformerFactory(informers.NewSharedInformerFactory(client, 0)),
+        frameworkruntime.WithEventRecorder(&events.FakeRecorder{}),
+        frameworkruntime.WithSnapshotSharedLister(s.snapshot()),
+        frameworkruntime.WithLogger(klog.FromContext(s.ctx)))
+    if err != nil {
+        return fmt.Errorf("building framework: %w", err)
+    }
+    s.fwk = fwk
+    return nil
+}
+
+func newPod(spec PodSpec) *v1.Pod {
+    return &v1.Pod{
+        ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: metav1.NamespaceDefault, UID: types.UID(spec.Name)},
+        Spec:       v1.PodSpec{SchedulerName: SchedulerName},
+    }
+}
+
+// report has the node's agent report a signal and queues its next report.
+func (s *simulator) report(n *simNode) {
+    s.observe(n)
+    s.queue.schedule(s.now+s.cfg.Load.SignalInterval.Duration, func() { s.report(n) })
+}
+
+// observe ingests the signal the node's agent computes from its current
+// utilization.
+func (s *simulator) observe(n *simNode) {
+    cpu := n.cpu() + s.cfg.Load.Noise*s.rng.NormFloat64()
+    memory := n.memoryUtilization()
+    if n.memory > 0 {
+        memory += s.cfg.Load.Noise * s.rng.NormFloat64()
+    }
+    features := []float64{clamp01(cpu), clamp01(memory), clamp01(cpu - 1), clamp01(memory - 1), 0}
+    signal, capacity, overprovision := s.model.Compute(features)
+    s.pl.Ingest(sourceName, n.node.Name,
+        plugin.WithSignal(signal), plugin.WithCapacity(capacity), plugin.WithOverprovision(overprovision))
+}
+
+// attempt runs a scheduling cycle for the pod and either places it,
+// retries it later or rejects it.
+func (s *simulator) attempt(p *simPod) {
+    p.attempts++
+    s.result.Attempts++
+    start := time.Now()
+    nodeName, ok := s.scheduleOne(p.pod)
+    s.cycles = append(s.cycles, time.Since(start))
+
+    if !ok {
+        s.result.Unschedulable++
+        if p.attempts >= s.cfg.MaxAttempts {
+            s.result.Rejected++
+            return
+        }
+        s.queue.schedule(s.now+s.cfg.RetryInterval.Duration, func() { s.attempt(p) })
+        return
+    }
+
+    s.result.Scheduled++
+    s.placement = append(s.placement, s.now-p.spec.Arrival)
+    n := s.byName[nodeName]
+    s.queue.schedule(s.now+s.cfg.StartupDelay.Duration, func() { s.start(p, n) })
+}
+
+// scheduleOne runs the framework's extension points for the pod as the
+// scheduler would and returns the node it was reserved on.
+func (s *simulator) scheduleOne(pod *v1.Pod) (string, bool) {
+    ctx := s.ctx
+    state := framework.NewCycleState()
+    if _, status := s.fwk.RunPreFilterPlugins(ctx, state, pod); !status.IsSuccess() {
+        return "", false
+    }
+
+    statuses := framework.NodeToStatusMap{}
+    var feasible []*v1.Node
+    for _, n := range s.nodes {
+        if status := s.fwk.RunFilterPlugins(ctx, state, pod, n.info); !status.IsSuccess() {
+            statuses[n.node.Name] = status
+            continue
+        }
+        feasible = append(feasible, n.node)
+    }
+    if len(feasible) == 0 {
+        s.fwk.RunPostFilterPlugins(ctx, state, pod, statuses)
+        return "", false
+    }
+
+    if status := s.fwk.RunPreScorePlugins(ctx, state, pod, feasible); !status.IsSuccess() && !status.IsSkip() {
+        return "", false
+    }
+    scores, status := s.fwk.RunScorePlugins(ctx, state, pod, feasible)
+    if !status.IsSuccess() {
+        return "", false
+    }
+    nodeName := s.selectHost(scores)
+
+    if status := s.fwk.RunReservePluginsReserve(ctx, state, pod, nodeName); !status.IsSuccess() {
+        s.fwk.RunReservePluginsUnreserve(ctx, state, pod, nodeName)
+        return "", false
+    }
+    // Binding always succeeds in the simulation.
+    s.fwk.RunPostBindPlugins(ctx, state, pod, nodeName)
+    return nodeName, true
+}
+
+// selectHost picks the highest scoring node, breaking ties uniformly at
+// random as the scheduler does.
+func (s *simulator) selectHost(scores []framework.NodePluginScores) string {
+    best, ties := 0, 1
+    for i := 1; i < len(scores); i++ {
+        switch {
+        case scores[i].TotalScore > scores[best].TotalScore:
+            best, ties = i, 1
+        case scores[i].TotalScore == scores[best].TotalScore:
+            ties++
+            if s.rng.Intn(ties) == 0 {
+                best = i
+            }
+        }
+    }
+    return scores[best].Name
+}
+
+// start runs the pod on its node, releasing its reservation as the pod
+// informer would, and queues its completion.
+func (s *simulator) start(p *simPod, n *simNode) {
+    s.account(n)
+    // Only pods starting raise a node's utilization, so they alone can
+    // start an overload incident.
+    wasOverloaded := n.utilization() > s.cfg.OverloadThreshold
+    n.load += p.spec.Load
+    n.used += p.spec.Memory
+    if !wasOverloaded && n.utilization() > s.cfg.OverloadThreshold {
+        n.incidents++
+    }
+    s.pl.UnReservePod(p.pod.Name, n.node.Name, "started")
+    if p.spec.Duration > 0 {
+        s.queue.schedule(s.now+p.spec.Duration, func() { s.finish(p, n) })
+    }
+}
+
+func (s *simulator) finish(p *simPod, n *simNode) {
+    s.account(n)
+    n.load = math.Max(0, n.load-p.spec.Load)
+    n.used = math.Max(0, n.used-p.spec.Memory)
+}
+
+// account accumulates the node's utilization and overload up to now.
+func (s *simulator) account(n *simNode) {
+    dt := s.now - n.since
+    u := n.utilization()
+    n.busy += u * dt.Seconds()
+    if u > s.cfg.OverloadThreshold {
+        n.overloaded += dt
+    }
+    n.since = s.now
+}
+
+func (s *simulator) sample() {
+    util := make([]float64, len(s.nodes))
+    for i, n := range s.nodes {
+        util[i] = n.utilization()
+    }
+    s.result.Samples = append(s.result.Samples, Sample{Time: s.now, Utilization: util})
+    s.queue.schedule(s.now+s.cfg.SampleInterval.Duration, s.sample)
+}
+
+func clamp01(v float64) float64 {
+    return math.Min(1, math.Max(0, v))
+}
@@ -0,0 +1,73 @@
+package sim
+
+import (
+    "context"
+    "reflect"
+    "testing"
+    "time"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testConfig is a short run of the default config whose plugin draws random
+// numbers, by sampling nodes and scoring them by Thompson sampling.
+func testConfig() *Config {
+    cfg := DefaultConfig()
+    cfg.Horizon = metav1.Duration{Duration: 10 * time.Minute}
+    cfg.Nodes[0].Count = 4
+    cfg.Workload.ArrivalRate = 0.1
+    cfg.PluginArgs = map[string]interface{}{
+        "scoringStrategy": map[string]interface{}{"type": "ThompsonSampling", "confidence": 1},
+        "sampling":        map[string]interface{}{"k": 2},
+    }
+    return cfg
+}
+
+// deterministic clears the wall-clock metrics of r, which no two runs share.
+func deterministic(r *Result) *Result {
+    r.CycleLatency = Latency{}
+    return r
+}
+
+func TestRunDeterministic(t *testing.T) {
+    cfg := testConfig()
+    pods := GenerateWorkload(cfg)
+    if len(pods) == 0 {
+        t.Fatal("the test workload has no pods")
+    }
+    first, err := Run(context.Background(), cfg, pods)
+    if err != nil {
+        t.Fatal(err)
+    }
+    second, err := Run(context.Background(), cfg, pods)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if first.Scheduled == 0 {
+        t.Error("no pod was scheduled")
+    }
+    if !reflect.DeepEqual(deterministic(first), deterministic(second)) {
+        t.Errorf("two runs of the same config differ:\n%+v\n%+v", first, second)
+    }
+}
+
+func TestPluginSeed(t *testing.T) {
+    for _, tc := range []struct {
+        name string
+        seed int64
+        args map[string]interface{}
+        want int64
+    }{
+        {name: "default", want: defaultPluginSeed},
+        {name: "config seed", seed: 7, want: 7},
+        {name: "plugin args seed", seed: 7, args: map[string]interface{}{"seed": float64(9)}, want: 9},
+        {name: "zero plugin args seed", seed: 7, args: map[string]interface{}{"seed": 0}, want: 7},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            cfg := &Config{Seed: tc.seed, PluginArgs: tc.args}
+            if got := cfg.PluginSeed(); got != tc.want {
+                t.Errorf("PluginSeed() = %d, want %d", got, tc.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,44 @@
+package sim
+
+import (
+    "fmt"
+
+    "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// snapshot is the framework's view of the simulated nodes.
+type snapshot struct {
+    s *simulator
+}
+
+var _ framework.SharedLister = snapshot{}
+
+func (s *simulator) snapshot() snapshot { return snapshot{s: s} }
+
+func (sn snapshot) NodeInfos() framework.NodeInfoLister { return sn }
+
+func (sn snapshot) StorageInfos() framework.StorageInfoLister { return sn }
+
+func (sn snapshot) List() ([]*framework.NodeInfo, error) {
+    infos := make([]*framework.NodeInfo, len(sn.s.nodes))
+    for i, n := range sn.s.nodes {
+        infos[i] = n.info
+    }
+    return infos, nil
+}
+
+func (sn snapshot) HavePodsWithAffinityList() ([]*framework.NodeInfo, error) { return nil, nil }
+
+func (sn snapshot) HavePodsWithRequiredAntiAffinityList() ([]*framework.NodeInfo, error) {
+    return nil, nil
+}
+
+func (sn snapshot) Get(nodeName string) (*framework.NodeInfo, error) {
+    n, ok := sn.s.byName[nodeName]
+    if !ok {
+        return nil, fmt.Errorf("nodeinfo not found for node name %q", nodeName)
+    }
+    return n.info, nil
+}
+
+func (sn snapshot) IsPVCUsedByPods(string) bool { return false }
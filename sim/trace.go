@@ -0,0 +1,183 @@
+package sim
+
+import (
+    "bufio"
+    "compress/gzip"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "strings"
+    "time"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TraceFormat is a format ReadTrace imports.
+type TraceFormat string
+
+const (
+    // PodsTrace is JSON lines of PodSpecs, as written by WriteTrace.
+    PodsTrace TraceFormat = "Pods"
+    // BorgTrace is the instance_events table of the Google cluster-data
+    // 2019 traces, exported as JSON lines.
+    BorgTrace TraceFormat = "Borg"
+    // AlibabaTaskTrace is batch_task.csv of the Alibaba cluster-trace-v2018.
+    AlibabaTaskTrace TraceFormat = "AlibabaTask"
+    // AlibabaInstanceTrace is batch_instance.csv of the Alibaba
+    // cluster-trace-v2018.
+    AlibabaInstanceTrace TraceFormat = "AlibabaInstance"
+    // AuditTrace is a Pronto audit log.
+    AuditTrace TraceFormat = "Audit"
+)
+
+// TraceConfig replays the pods of a trace instead of generating a synthetic
+// workload.
+type TraceConfig struct {
+    // Path is the trace file, gzipped if it ends in .gz. The workload is
+    // synthetic if it is empty.
+    Path   string      `json:"path,omitempty"`
+    Format TraceFormat `json:"format,omitempty"`
+    // Offset skips the start of the trace: pods arriving within Offset of
+    // the trace's first arrival are dropped and the rest arrive Offset
+    // earlier.
+    Offset metav1.Duration `json:"offset,omitempty"`
+    // MaxPods limits the trace to its first MaxPods pods if set.
+    MaxPods int `json:"maxPods,omitempty"`
+    // CPUScale and MemoryScale convert the trace's CPU and memory into the
+    // units of the nodes' Capacity and MemoryCapacity.
+    CPUScale    float64 `json:"cpuScale,omitempty"`
+    MemoryScale float64 `json:"memoryScale,omitempty"`
+    // Duration and Load stand in for a pod's run time and load when the
+    // trace doesn't record them, as in an audit log.
+    Duration metav1.Duration `json:"duration,omitempty"`
+    Load     float64         `json:"load,omitempty"`
+}
+
+func defaultTraceConfig() TraceConfig {
+    return TraceConfig{
+        Format:      PodsTrace,
+        CPUScale:    1,
+        MemoryScale: 1,
+        Duration:    metav1.Duration{Duration: 10 * time.Minute},
+        Load:        0.5,
+    }
+}
+
+func (t *TraceConfig) validate() error {
+    if _, ok := traceReaders[t.Format]; !ok {
+        return fmt.Errorf("unknown trace format %q", t.Format)
+    }
+    if t.Offset.Duration < 0 || t.MaxPods < 0 || t.Duration.Duration < 0 || t.Load < 0 {
+        return fmt.Errorf("offset, maxPods, duration and load must not be negative")
+    }
+    if t.CPUScale <= 0 || t.MemoryScale <= 0 {
+        return fmt.Errorf("cpuScale and memoryScale must be positive")
+    }
+    return nil
+}
+
+// traceReader reads the pods of a trace, with arrivals relative to any
+// fixed origin.
+type traceReader func(r io.Reader, cfg *TraceConfig) ([]PodSpec, error)
+
+var traceReaders = map[TraceFormat]traceReader{
+    PodsTrace:            readPodsTrace,
+    BorgTrace:            readBorgTrace,
+    AlibabaTaskTrace:     readAlibabaTaskTrace,
+    AlibabaInstanceTrace: readAlibabaInstanceTrace,
+    AuditTrace:           readAuditTrace,
+}
+
+// LoadWorkload returns the pods of the configured trace, or a synthetic
+// workload if there is none.
+func LoadWorkload(cfg *Config) ([]PodSpec, error) {
+    if cfg.Trace.Path == "" {
+        return GenerateWorkload(cfg), nil
+    }
+    f, err := os.Open(cfg.Trace.Path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    var r io.Reader = f
+    if strings.HasSuffix(cfg.Trace.Path, ".gz") {
+        gz, err := gzip.NewReader(f)
+        if err != nil {
+            return nil, fmt.Errorf("reading %s: %w", cfg.Trace.Path, err)
+        }
+        defer gz.Close()
+        r = gz
+    }
+    pods, err := ReadTrace(r, &cfg.Trace)
+    if err != nil {
+        return nil, fmt.Errorf("reading %s: %w", cfg.Trace.Path, err)
+    }
+    return pods, nil
+}
+
+// ReadTrace imports the pods of a trace in cfg.Format. Pods are ordered by
+// arrival, then name, so replaying a trace is deterministic, and arrive
+// relative to the first pod of the trace unless it is a PodsTrace.
+func ReadTrace(r io.Reader, cfg *TraceConfig) ([]PodSpec, error) {
+    if err := cfg.validate(); err != nil {
+        return nil, err
+    }
+    pods, err := traceReaders[cfg.Format](r, cfg)
+    if err != nil {
+        return nil, err
+    }
+    if len(pods) == 0 {
+        return nil, nil
+    }
+
+    sort.Slice(pods, func(i, j int) bool {
+        if pods[i].Arrival != pods[j].Arrival {
+            return pods[i].Arrival < pods[j].Arrival
+        }
+        return pods[i].Name < pods[j].Name
+    })
+    // A PodsTrace already arrives relative to the start of a simulation.
+    origin := pods[0].Arrival
+    if cfg.Format == PodsTrace {
+        origin = 0
+    }
+    start := origin + cfg.Offset.Duration
+    first := sort.Search(len(pods), func(i int) bool { return pods[i].Arrival >= start })
+    pods = pods[first:]
+    if cfg.MaxPods > 0 && len(pods) > cfg.MaxPods {
+        pods = pods[:cfg.MaxPods]
+    }
+    for i := range pods {
+        pods[i].Arrival -= start
+    }
+    return pods, nil
+}
+
+// WriteTrace writes pods as a PodsTrace.
+func WriteTrace(w io.Writer, pods []PodSpec) error {
+    bw := bufio.NewWriter(w)
+    enc := json.NewEncoder(bw)
+    for i := range pods {
+        if err := enc.Encode(&pods[i]); err != nil {
+            return err
+        }
+    }
+    return bw.Flush()
+}
+
+func readPodsTrace(r io.Reader, _ *TraceConfig) ([]PodSpec, error) {
+    var pods []PodSpec
+    dec := json.NewDecoder(r)
+    for {
+        var pod PodSpec
+        if err := dec.Decode(&pod); err == io.EOF {
+            return pods, nil
+        } else if err != nil {
+            return nil, fmt.Errorf("pod %d: %w", len(pods), err)
+        }
+        pods = append(pods, pod)
+    }
+}
@@ -0,0 +1,195 @@
+package sim
+
+import (
+    "bytes"
+    "os"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func readTestTrace(t *testing.T, path string, cfg *TraceConfig) []PodSpec {
+    t.Helper()
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    pods, err := ReadTrace(f, cfg)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return pods
+}
+
+func traceConfig(format TraceFormat) *TraceConfig {
+    cfg := defaultTraceConfig()
+    cfg.Format = format
+    return &cfg
+}
+
+func TestReadTraceFormats(t *testing.T) {
+    borg := traceConfig(BorgTrace)
+    borg.CPUScale, borg.MemoryScale = 8, 16
+    tests := []struct {
+        name string
+        path string
+        cfg  *TraceConfig
+        want []PodSpec
+    }{
+        {
+            // The request made after scheduling and the events after the
+            // first end are ignored, as are unscheduled instances and events
+            // after the trace window.
+            name: "borg",
+            path: "testdata/borg.json",
+            cfg:  borg,
+            want: []PodSpec{
+                {Name: "borg-1-0", Arrival: 0, Duration: 10 * time.Second, Load: 4, Memory: 4},
+                {Name: "borg-1-1", Arrival: time.Second, Load: 1, Memory: 1},
+            },
+        },
+        {
+            // Tasks without a start or end time are skipped.
+            name: "alibaba task",
+            path: "testdata/alibaba_task.csv",
+            cfg:  traceConfig(AlibabaTaskTrace),
+            want: []PodSpec{
+                {Name: "j_1-task_1-0", Duration: time.Minute, Load: 0.5, Memory: 0.25},
+                {Name: "j_1-task_1-1", Duration: time.Minute, Load: 0.5, Memory: 0.25},
+                {Name: "j_2-task_4-0", Arrival: 30 * time.Second, Duration: time.Minute, Load: 2, Memory: 0.5},
+            },
+        },
+        {
+            // Instances without usage are skipped.
+            name: "alibaba instance",
+            path: "testdata/alibaba_instance.csv",
+            cfg:  traceConfig(AlibabaInstanceTrace),
+            want: []PodSpec{
+                {Name: "j_1-task_2-ins_3-2", Duration: time.Minute, Load: 0.5, Memory: 0.05},
+                {Name: "j_1-task_1-ins_1-1", Arrival: 10 * time.Second, Duration: time.Minute, Load: 1.5, Memory: 0.1},
+            },
+        },
+        {
+            // Each pod arrives at its first cycle, and a pod recreated under
+            // the same name gets a name of its own.
+            name: "audit",
+            path: "testdata/audit.json",
+            cfg:  traceConfig(AuditTrace),
+            want: []PodSpec{
+                {Name: "default/web", Duration: 10 * time.Minute, Load: 0.5},
+                {Name: "team-a/web", Arrival: 2 * time.Second, Duration: 10 * time.Minute, Load: 0.5},
+                {Name: "default/web#1", Arrival: time.Minute, Duration: 10 * time.Minute, Load: 0.5},
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := readTestTrace(t, tt.path, tt.cfg)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("ReadTrace() =\n%+v\nwant\n%+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestReadTraceOffsetAndMaxPods(t *testing.T) {
+    var trace bytes.Buffer
+    if err := WriteTrace(&trace, []PodSpec{
+        {Name: "b", Arrival: 5 * time.Second},
+        {Name: "d", Arrival: 10 * time.Second},
+        {Name: "a", Arrival: 5 * time.Second},
+        {Name: "c", Arrival: time.Second},
+    }); err != nil {
+        t.Fatal(err)
+    }
+    tests := []struct {
+        name    string
+        offset  time.Duration
+        maxPods int
+        want    []PodSpec
+    }{
+        {
+            name: "ordered by arrival then name",
+            want: []PodSpec{
+                {Name: "c", Arrival: time.Second},
+                {Name: "a", Arrival: 5 * time.Second},
+                {Name: "b", Arrival: 5 * time.Second},
+                {Name: "d", Arrival: 10 * time.Second},
+            },
+        },
+        {
+            // A Pods trace is offset from the start of the simulation, not
+            // from its first pod.
+            name:   "offset",
+            offset: 2 * time.Second,
+            want: []PodSpec{
+                {Name: "a", Arrival: 3 * time.Second},
+                {Name: "b", Arrival: 3 * time.Second},
+                {Name: "d", Arrival: 8 * time.Second},
+            },
+        },
+        {
+            name:    "offset and max pods",
+            offset:  2 * time.Second,
+            maxPods: 2,
+            want: []PodSpec{
+                {Name: "a", Arrival: 3 * time.Second},
+                {Name: "b", Arrival: 3 * time.Second},
+            },
+        },
+        {
+            name:   "offset past the end",
+            offset: time.Minute,
+            want:   []PodSpec{},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            cfg := traceConfig(PodsTrace)
+            cfg.Offset = metav1.Duration{Duration: tt.offset}
+            cfg.MaxPods = tt.maxPods
+            got, err := ReadTrace(bytes.NewReader(trace.Bytes()), cfg)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("ReadTrace() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+
+    // Other traces are offset from their first pod.
+    cfg := traceConfig(AlibabaTaskTrace)
+    cfg.Offset = metav1.Duration{Duration: 20 * time.Second}
+    got := readTestTrace(t, "testdata/alibaba_task.csv", cfg)
+    want := []PodSpec{{Name: "j_2-task_4-0", Arrival: 10 * time.Second, Duration: time.Minute, Load: 2, Memory: 0.5}}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("offset Alibaba trace = %+v, want %+v", got, want)
+    }
+}
+
+func TestReadTraceErrors(t *testing.T) {
+    tests := []struct {
+        name  string
+        cfg   *TraceConfig
+        trace string
+    }{
+        {name: "unknown format", cfg: traceConfig("Slurm"), trace: ""},
+        {name: "negative offset", cfg: &TraceConfig{Format: PodsTrace, CPUScale: 1, MemoryScale: 1, Offset: metav1.Duration{Duration: -time.Second}}},
+        {name: "malformed pod", cfg: traceConfig(PodsTrace), trace: `{"name": 1}`},
+        {name: "malformed borg event", cfg: traceConfig(BorgTrace), trace: `{"time": "soon"}`},
+        {name: "short alibaba row", cfg: traceConfig(AlibabaTaskTrace), trace: "task_1,1,j_1\n"},
+        {name: "malformed audit record", cfg: traceConfig(AuditTrace), trace: `{"time": "yesterday"}`},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if pods, err := ReadTrace(strings.NewReader(tt.trace), tt.cfg); err == nil {
+                t.Errorf("ReadTrace() = %+v, want an error", pods)
+            }
+        })
+    }
+}
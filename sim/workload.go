@@ -0,0 +1,96 @@
+package sim
+
+import (
+    "fmt"
+    "math"
+    "math/rand"
+    "time"
+)
+
+// PodSpec is a pod submitted to the simulated scheduler.
+type PodSpec struct {
+    Name string `json:"name"`
+    // Arrival is the time since the start of the simulation at which the
+    // pod is submitted.
+    Arrival time.Duration `json:"arrival"`
+    // Duration is how long the pod runs once started. A pod with no
+    // Duration runs until the end of the simulation.
+    Duration time.Duration `json:"duration,omitempty"`
+    // Load is the load the pod puts on its node, in the units of the
+    // nodes' Capacity.
+    Load float64 `json:"load"`
+    // Memory is the memory the pod uses, in the units of the nodes'
+    // MemoryCapacity.
+    Memory float64 `json:"memory,omitempty"`
+}
+
+func (d Distribution) validate() error {
+    switch d.Type {
+    case Constant:
+        if d.Value < 0 {
+            return fmt.Errorf("value must not be negative")
+        }
+    case Uniform:
+        if d.Min < 0 || d.Max < d.Min {
+            return fmt.Errorf("min must not be negative or above max")
+        }
+    case Exponential:
+        if d.Mean <= 0 {
+            return fmt.Errorf("mean must be positive")
+        }
+    case LogNormal:
+        if d.Sigma < 0 {
+            return fmt.Errorf("sigma must not be negative")
+        }
+    default:
+        return fmt.Errorf("unknown distribution type %q", d.Type)
+    }
+    return nil
+}
+
+// Sample draws a value from the distribution.
+func (d Distribution) Sample(rng *rand.Rand) float64 {
+    switch d.Type {
+    case Uniform:
+        return d.Min + rng.Float64()*(d.Max-d.Min)
+    case Exponential:
+        return rng.ExpFloat64() * d.Mean
+    case LogNormal:
+        return math.Exp(d.Mu + d.Sigma*rng.NormFloat64())
+    default:
+        return d.Value
+    }
+}
+
+// GenerateWorkload draws the pods arriving within the horizon of cfg.
+func GenerateWorkload(cfg *Config) []PodSpec {
+    rng := rand.New(rand.NewSource(cfg.Seed))
+    w := cfg.Workload
+    if w.ArrivalRate == 0 {
+        return nil
+    }
+
+    var pods []PodSpec
+    var at float64
+    for {
+        at += rng.ExpFloat64() / w.ArrivalRate
+        arrival := seconds(at)
+        if arrival >= cfg.Horizon.Duration {
+            return pods
+        }
+        pod := PodSpec{
+            Name:     fmt.Sprintf("pod-%06d", len(pods)),
+            Arrival:  arrival,
+            Duration: seconds(w.Duration.Sample(rng)),
+            Load:     w.Load.Sample(rng),
+        }
+        if w.Memory != nil {
+            pod.Memory = w.Memory.Sample(rng)
+        }
+        pods = append(pods, pod)
+    }
+}
+
+func seconds(s float64) time.Duration {
+    return time.Duration(s * float64(time.Second))
+}